@@ -63,3 +63,43 @@ func (aabb Aabb) Hit(ray Ray, tMin, tMax float64) bool {
 		checkAxis(aabb.Min.Y, aabb.Max.Y, ray.Origin().Y, ray.Direction().Y) &&
 		checkAxis(aabb.Min.Z, aabb.Max.Z, ray.Origin().Z, ray.Direction().Z)
 }
+
+// Returns the eight corners of the box
+func (aabb Aabb) Corners() [8]Point3 {
+	var corners [8]Point3
+
+	for i := range corners {
+		corners[i] = aabb.Min
+
+		if i&1 != 0 {
+			corners[i].X = aabb.Max.X
+		}
+		if i&2 != 0 {
+			corners[i].Y = aabb.Max.Y
+		}
+		if i&4 != 0 {
+			corners[i].Z = aabb.Max.Z
+		}
+	}
+
+	return corners
+}
+
+// Returns the box that bounds all the points transformed by the given function, computed by transforming all the corners
+// so that it's correct for any affine transform
+func (aabb Aabb) TransformFunc(transform func(p Point3) Point3) Aabb {
+	corners := aabb.Corners()
+
+	bbox := NewAabb(transform(corners[0]), transform(corners[0]))
+
+	for _, corner := range corners[1:] {
+		p := transform(corner)
+		bbox = bbox.Union(NewAabb(p, p))
+	}
+
+	return bbox
+}
+
+func (aabb Aabb) Transform(m Mat4) Aabb {
+	return aabb.TransformFunc(m.TransformPoint)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Interpolation modes between keyframes
+const (
+	InterpolationLinear = iota
+	InterpolationSpline // Catmull-Rom spline, it passes through all the keyframes with a smooth velocity
+)
+
+type Keyframe struct {
+	Time  float64
+	Value Vec3
+}
+
+// A track animates a value by interpolating between keyframes, values are vectors (or colors) but tracks of numbers
+// are supported too, they use the X component only. Before the first keyframe and after the last one the value doesn't change.
+type Track struct {
+	keys          []Keyframe // Sorted by time
+	interpolation int
+}
+
+func NewTrack(interpolation int) *Track {
+	return &Track{interpolation: interpolation}
+}
+
+// Adds a keyframe, returns the track so that calls can be chained
+func (track *Track) Add(time float64, value Vec3) *Track {
+	i := sort.Search(len(track.keys), func(i int) bool { return track.keys[i].Time > time })
+
+	track.keys = append(track.keys, Keyframe{})
+	copy(track.keys[i+1:], track.keys[i:])
+	track.keys[i] = Keyframe{time, value}
+
+	return track
+}
+
+func (track *Track) AddFloat(time, value float64) *Track {
+	return track.Add(time, NewVec3(value, 0, 0))
+}
+
+// Returns the time of the first and of the last keyframe
+func (track *Track) TimeRange() (float64, float64) {
+	return track.keys[0].Time, track.keys[len(track.keys)-1].Time
+}
+
+func (track *Track) At(time float64) Vec3 {
+	keys := track.keys
+	n := len(keys)
+
+	if n == 0 {
+		panic("The track has no keyframes")
+	}
+
+	if time <= keys[0].Time {
+		return keys[0].Value
+	}
+	if time >= keys[n-1].Time {
+		return keys[n-1].Value
+	}
+
+	// Find the segment that contains the time, between keys i and i+1
+	i := sort.Search(n, func(i int) bool { return keys[i].Time > time }) - 1
+	k0, k1 := keys[i], keys[i+1]
+	duration := k1.Time - k0.Time
+	t := (time - k0.Time) / duration
+
+	if track.interpolation == InterpolationLinear {
+		return k0.Value.Mul(1 - t).Add(k1.Value.Mul(t))
+	}
+
+	// The tangent at every key is the velocity between the keys on its two sides, at the ends it's the velocity of the only segment
+	tangent := func(j int) Vec3 {
+		prev, next := j-1, j+1
+		if prev < 0 {
+			prev = 0
+		}
+		if next > n-1 {
+			next = n - 1
+		}
+		return keys[next].Value.Sub(keys[prev].Value).Div(keys[next].Time - keys[prev].Time)
+	}
+
+	m0, m1 := tangent(i).Mul(duration), tangent(i+1).Mul(duration)
+
+	// Cubic Hermite basis functions
+	t2, t3 := t*t, t*t*t
+	h00 := 2*t3 - 3*t2 + 1
+	h10 := t3 - 2*t2 + t
+	h01 := -2*t3 + 3*t2
+	h11 := t3 - t2
+
+	return k0.Value.Mul(h00).Add(m0.Mul(h10)).Add(k1.Value.Mul(h01)).Add(m1.Mul(h11))
+}
+
+func (track *Track) FloatAt(time float64) float64 {
+	return track.At(time).X
+}
+
+// Animates the camera parameters, tracks that are nil leave the parameter unchanged
+type CameraAnimation struct {
+	LookFrom      *Track
+	LookAt        *Track
+	Vfov          *Track
+	FocusDistance *Track
+	DefocusAngle  *Track
+}
+
+// Sets the camera parameters to their value at the given time
+func (ca CameraAnimation) Apply(camera *Camera, time float64) {
+	if ca.LookFrom != nil {
+		camera.SetLookFrom(ca.LookFrom.At(time))
+	}
+	if ca.LookAt != nil {
+		camera.SetLookAt(ca.LookAt.At(time))
+	}
+	if ca.Vfov != nil {
+		camera.SetVerticalFieldOfView(ca.Vfov.FloatAt(time))
+	}
+	if ca.FocusDistance != nil {
+		camera.SetFocusDistance(ca.FocusDistance.FloatAt(time))
+	}
+	if ca.DefocusAngle != nil {
+		camera.SetDefocusAngle(ca.DefocusAngle.FloatAt(time))
+	}
+}
+
+// An animated scene is built again for every frame, the time is the time of the frame so that the scene can set
+// time-dependent parameters (e.g. material colors) from tracks. The camera animation is applied on top of the returned camera,
+// at the middle of the shutter interval of every frame.
+type AnimatedScene func(time float64) (Camera, CameraAnimation, Hittable)
+
+// Returns the bounding box of an object that moves according to a time-dependent transform, from startTime to endTime.
+// The motion is sampled at regular intervals, then the result is padded by the largest distance a corner travels between
+// two samples, as along a curved path a corner may get out of the sampled boxes.
+func motionBoundingBox(objectBox Aabb, transformAt func(time float64) Mat4, startTime, endTime float64) Aabb {
+	const steps = 64
+
+	var bbox Aabb
+	var prevCorners [8]Point3
+	pad := 0.0
+
+	for i := 0; i <= steps; i++ {
+		m := transformAt(startTime + (endTime-startTime)*float64(i)/steps)
+
+		corners := objectBox.Corners()
+		for j := range corners {
+			corners[j] = m.TransformPoint(corners[j])
+
+			if i > 0 {
+				pad = math.Max(pad, corners[j].Sub(prevCorners[j]).Length())
+			}
+		}
+
+		if i == 0 {
+			bbox = NewAabb(corners[0], corners[0])
+		}
+		for _, corner := range corners {
+			bbox = bbox.Union(NewAabb(corner, corner))
+		}
+
+		prevCorners = corners
+	}
+
+	padding := NewVec3(pad/2, pad/2, pad/2)
+
+	return NewAabb(bbox.Min.Sub(padding), bbox.Max.Add(padding))
+}
@@ -0,0 +1,32 @@
+package main
+
+// Cornell box animation: the tall box spins and slides, the light warms up and the camera dollies in
+func Animation1(time float64) (Camera, CameraAnimation, Hittable) {
+	world := NewHittableList()
+
+	// Material parameters are animated by building the material again for every frame
+	lightColor := NewTrack(InterpolationLinear).Add(0, NewColor(15, 15, 15)).Add(2, NewColor(18, 12, 6))
+	light := NewDiffuseLight(NewSolidColorTexture(lightColor.At(time)))
+
+	white := createCornellBox(&world, light)
+
+	box1 := createBox(NewPoint3(-82.5, 0, -82.5), NewPoint3(82.5, 330, 82.5), white)
+	translation := NewTrack(InterpolationSpline).Add(0, NewVec3(347, 0, 377)).Add(1, NewVec3(300, 0, 330)).Add(2, NewVec3(347, 0, 377))
+	rotation := NewTrack(InterpolationSpline).Add(0, NewVec3(0, 15, 0)).Add(2, NewVec3(0, 195, 0))
+	world.Add(NewKeyframedTransform(box1, translation, rotation, nil))
+
+	box2 := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 165, 165), white)
+	world.Add(NewTranslate(NewRotateY(box2, -18), NewVec3(130, 0, 65)))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookAt(NewPoint3(278, 278, 0))
+	cam.SetVerticalFieldOfView(40)
+	cam.SetBackground(NewColor(0, 0, 0))
+	cam.SetImageWidth(300)
+	cam.SetRenderingParams(50, 50)
+
+	animation := CameraAnimation{LookFrom: NewTrack(InterpolationSpline).Add(0, NewPoint3(278, 278, -800)).Add(2, NewPoint3(278, 278, -600))}
+
+	return cam, animation, world
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnimationOptions(t *testing.T) {
+	// The options that don't apply to animations are rejected before rendering
+	for _, options := range []Options{
+		{aov: AovDepth},
+		{denoise: true},
+		{fireflies: 5},
+		{robust: 5},
+		{workers: 2},
+		{crop: "0,0,10,10"},
+		{timeBudget: 1},
+	} {
+		options.animation, options.frames, options.fps, options.shutterAngle = 1, "0", 24, 180
+
+		if err := runAnimation(options); err == nil {
+			t.Errorf("an animation was rendered with %+v", options)
+		}
+	}
+}
+
+func TestTrackLinear(t *testing.T) {
+	// The keys are sorted by time when they are added
+	track := NewTrack(InterpolationLinear).Add(3, NewVec3(4, 2, 6)).Add(0, NewVec3(0, 0, 0)).Add(2, NewVec3(4, 2, 0))
+
+	for _, test := range []struct {
+		time float64
+		want Vec3
+	}{
+		{-1, NewVec3(0, 0, 0)},
+		{0, NewVec3(0, 0, 0)},
+		{1, NewVec3(2, 1, 0)},
+		{2, NewVec3(4, 2, 0)},
+		{2.5, NewVec3(4, 2, 3)},
+		{3, NewVec3(4, 2, 6)},
+		{10, NewVec3(4, 2, 6)},
+	} {
+		if got := track.At(test.time); !nearVec3(got, test.want, 1e-12) {
+			t.Errorf("at time %g the track is %v, want %v", test.time, got, test.want)
+		}
+	}
+
+	if first, last := track.TimeRange(); first != 0 || last != 3 {
+		t.Errorf("the track goes from %g to %g, want from 0 to 3", first, last)
+	}
+}
+
+func TestTrackSpline(t *testing.T) {
+	track := NewTrack(InterpolationSpline).AddFloat(0, 0).AddFloat(1, 1).AddFloat(2, 0)
+
+	for _, test := range []struct{ time, want float64 }{
+		{-1, 0},
+		{0, 0},
+		{0.5, 0.625}, // The tangent is 1 at the first key and 0 at the peak
+		{1, 1},
+		{1.5, 0.625},
+		{2, 0},
+		{3, 0},
+	} {
+		if got := track.At(test.time).X; math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("at time %g the spline is %g, want %g", test.time, got, test.want)
+		}
+	}
+
+	// Evenly spaced keys on a line are interpolated along the line
+	line := NewTrack(InterpolationSpline).Add(0, NewVec3(0, 0, 0)).Add(1, NewVec3(1, 2, 3)).Add(2, NewVec3(2, 4, 6))
+	if got := line.At(0.25); !nearVec3(got, NewVec3(0.25, 0.5, 0.75), 1e-12) {
+		t.Errorf("the spline through a line is %v at time 0.25, want %v", got, NewVec3(0.25, 0.5, 0.75))
+	}
+}
+
+func TestParseFrameRange(t *testing.T) {
+	for _, test := range []struct {
+		frames      string
+		first, last int
+		valid       bool
+	}{
+		{"0-47", 0, 47, true},
+		{"12", 12, 12, true},
+		{"5-5", 5, 5, true},
+		{"47-0", 0, 0, false},
+		{"1-", 0, 0, false},
+		{"-3", 0, 0, false},
+		{"a-b", 0, 0, false},
+		{"", 0, 0, false},
+	} {
+		first, last, err := parseFrameRange(test.frames)
+
+		if (err == nil) != test.valid || first != test.first || last != test.last {
+			t.Errorf("the frame range %q is %d-%d with error %v, want %d-%d valid %v", test.frames, first, last, err, test.first, test.last, test.valid)
+		}
+	}
+}
+
+func TestFrameFilename(t *testing.T) {
+	for _, test := range []struct {
+		output string
+		frame  int
+		want   string
+	}{
+		{"anim.png", 3, "anim_0003.png"},
+		{"anim.png", 12345, "anim_12345.png"},
+		{"dir/out", 1, "dir/out_0001"},
+		{"frame%03d.ppm", 7, "frame007.ppm"},
+		{"frame%d.ppm", 7, "frame7.ppm"},
+	} {
+		if got := frameFilename(test.output, test.frame); got != test.want {
+			t.Errorf("frame %d of %s is written to %s, want %s", test.frame, test.output, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Arbitrary output variables (AOVs): auxiliary images recorded at the first hit of every camera ray, alongside the color.
+// They are stored as layers of the film, named as follows.
+const (
+	AovDepth      = "depth"    // Distance of the hit along the view direction (from the camera center, for the panoramic projections), 0 is the background
+	AovNormal     = "normal"   // World space normal, facing the camera
+	AovAlbedo     = "albedo"   // Attenuation of the material
+	AovPosition   = "position" // World space hit point
+	AovUV         = "uv"       // Surface coordinates, in the first two channels
+	AovObjectID   = "object"   // Number of the object in its scene list, starting from 1 (0 is the background)
+	AovMaterialID = "material" // Number of the material, starting from 1 (0 is the background), see materialRegistry
+	AovVariance   = "variance" // Variance of the color samples of the pixel (the film sums their squares)
+)
+
+var AovNames = []string{AovDepth, AovNormal, AovAlbedo, AovPosition, AovUV, AovObjectID, AovMaterialID, AovVariance}
+
+// ID layers hold the value of the first sample of every pixel, as averaging IDs makes no sense
+func isIDLayer(name string) bool {
+	return name == AovObjectID || name == AovMaterialID
+}
+
+// The values of the auxiliary outputs for one sample
+type AovSample struct {
+	Color      Color
+	Depth      float64
+	Normal     Vec3
+	Albedo     Color
+	Position   Point3
+	U, V       float64
+	ObjectID   int
+	MaterialID int
+}
+
+func (aov AovSample) Value(name string) Color {
+	switch name {
+	case AovDepth:
+		return NewColor(aov.Depth, aov.Depth, aov.Depth)
+	case AovNormal:
+		return aov.Normal
+	case AovAlbedo:
+		return aov.Albedo
+	case AovPosition:
+		return aov.Position
+	case AovUV:
+		return NewColor(aov.U, aov.V, 0)
+	case AovObjectID:
+		return NewColor(float64(aov.ObjectID), float64(aov.ObjectID), float64(aov.ObjectID))
+	case AovMaterialID:
+		return NewColor(float64(aov.MaterialID), float64(aov.MaterialID), float64(aov.MaterialID))
+	case AovVariance:
+		return aov.Color.MultiplyByComponent(aov.Color)
+	}
+
+	panic("Unknown AOV: " + name)
+}
+
+// Numbers the materials of a scene, starting from 1, in the order they are found walking the scene when it's built:
+// the numbers don't depend on what's rendered, so they match across resumed, cropped and distributed renders.
+// Materials with the same parameters get the same number, the ones that can't be compared (e.g. image textures)
+// get the same number if they share their data.
+type materialRegistry struct {
+	ids map[any]int
+}
+
+func newMaterialRegistry(world Hittable) *materialRegistry {
+	registry := &materialRegistry{ids: map[any]int{}}
+	registry.addObject(world)
+
+	return registry
+}
+
+// Numbers the materials of an object and of the objects it contains
+func (registry *materialRegistry) addObject(object Hittable) {
+	switch h := object.(type) {
+	case Sphere:
+		registry.add(h.mat)
+	case Quad:
+		registry.add(h.mat)
+	case ConstantMedium:
+		// The hits of a volume get its phase function, never the material of its boundary
+		registry.add(h.phaseFunction)
+		return
+	}
+
+	for _, child := range hittableChildren(object) {
+		registry.addObject(child)
+	}
+}
+
+func (registry *materialRegistry) add(mat Material) {
+	key := materialKey(mat)
+
+	if _, ok := registry.ids[key]; !ok {
+		registry.ids[key] = len(registry.ids) + 1
+	}
+}
+
+// Returns the number of a material, 0 if it's not part of the scene
+func (registry *materialRegistry) id(mat Material) int {
+	return registry.ids[materialKey(mat)]
+}
+
+// Returns a map key for a material: the material itself if it can be compared, otherwise a description of its type
+// and values where the slices, maps, functions and pointers are replaced by their addresses
+func materialKey(mat Material) any {
+	v := reflect.ValueOf(mat)
+
+	if isHashable(v) {
+		return mat
+	}
+
+	var key strings.Builder
+	writeValueKey(&key, v)
+
+	return key.String()
+}
+
+func writeValueKey(key *strings.Builder, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Slice:
+		fmt.Fprintf(key, "%x[%d]", v.Pointer(), v.Len())
+	case reflect.Map, reflect.Func, reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		fmt.Fprintf(key, "%x", v.Pointer())
+	case reflect.Interface:
+		if v.IsNil() {
+			key.WriteString("nil")
+		} else {
+			writeValueKey(key, v.Elem())
+		}
+	case reflect.Struct:
+		key.WriteString(v.Type().String() + "{")
+		for i := 0; i < v.NumField(); i++ {
+			writeValueKey(key, v.Field(i))
+			key.WriteString(",")
+		}
+		key.WriteString("}")
+	case reflect.Array:
+		key.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			writeValueKey(key, v.Index(i))
+			key.WriteString(",")
+		}
+		key.WriteString("]")
+	case reflect.Bool:
+		fmt.Fprint(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprint(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprint(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprint(key, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		fmt.Fprint(key, v.Complex())
+	case reflect.String:
+		fmt.Fprintf(key, "%q", v.String())
+	}
+}
+
+// Returns true if a value can be used as a map key, including the values held by its interfaces
+func isHashable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false
+	case reflect.Interface:
+		return v.IsNil() || isHashable(v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isHashable(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isHashable(v.Field(i)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Returns the color of a random sample of the pixel at location i, j, together with the auxiliary outputs of the first hit
+func (camera Camera) SampleAovs(world Hittable, i, j int) (Color, AovSample) {
+	ray, ok := camera.getRay(i, j)
+
+	if !ok || camera.maxRayDepth <= 0 {
+		return Color{0, 0, 0}, AovSample{}
+	}
+
+	raysTraced.Add(1)
+
+	rec := HitRecord{}
+
+	if !world.Hit(ray, 0.001, math.Inf(+1), &rec) {
+		color := filmColor(ray, camera.backgroundColor(ray))
+		return color, AovSample{Color: color}
+	}
+
+	color, attenuation := camera.shadeHit(ray, &rec, world, camera.maxRayDepth)
+	color, attenuation = filmColor(ray, color), filmColor(ray, attenuation)
+
+	aov := AovSample{Color: color, Normal: rec.Normal, Albedo: attenuation, Position: rec.P, U: rec.U, V: rec.V, ObjectID: rec.ObjectID}
+
+	if camera.projection == ProjectionFisheye || camera.projection == ProjectionEquirectangular {
+		aov.Depth = rec.T * ray.Direction().Length()
+	} else {
+		aov.Depth = rec.T * ray.Direction().Dot(camera.w.Negate())
+	}
+
+	if camera.materialIDs != nil {
+		aov.MaterialID = camera.materialIDs.id(rec.Mat)
+	}
+
+	return color, aov
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestMaterialRegistry(t *testing.T) {
+	red, green := NewLambertianMaterial(NewColor(1, 0, 0)), NewLambertianMaterial(NewColor(0, 1, 0))
+	image1 := NewTextureLambertianMaterial(ImageTexture{data: make([]Color, 4), width: 2, height: 2})
+	image2 := NewTextureLambertianMaterial(ImageTexture{data: make([]Color, 4), width: 2, height: 2})
+	unused := NewLambertianMaterial(NewColor(0, 0, 1))
+
+	list := NewHittableList()
+	list.Add(NewSphere(NewPoint3(0, 0, 0), 1, red))
+	list.Add(NewSphere(NewPoint3(3, 0, 0), 1, image1))
+	list.Add(NewSphere(NewPoint3(6, 0, 0), 1, NewLambertianMaterial(NewColor(1, 0, 0)))) // The same parameters as red
+
+	world := NewHittableList()
+	world.Add(NewBhvTree(list))
+	world.Add(NewTranslate(NewQuad(NewPoint3(0, 0, 0), NewVec3(1, 0, 0), NewVec3(0, 1, 0), image2), NewVec3(0, 5, 0)))
+	world.Add(NewConstantMedium(NewSphere(NewPoint3(0, 0, 10), 1, green), 0.1, NewSolidColorTexture(NewColor(1, 1, 1))))
+
+	registry := newMaterialRegistry(world)
+
+	if len(registry.ids) != 4 {
+		t.Errorf("the scene has %d materials, want 4", len(registry.ids))
+	}
+
+	if registry.id(red) == 0 || registry.id(red) != registry.id(NewLambertianMaterial(NewColor(1, 0, 0))) {
+		t.Errorf("materials with the same parameters have different numbers")
+	}
+
+	if registry.id(image1) == 0 || registry.id(image2) == 0 || registry.id(image1) == registry.id(image2) {
+		t.Errorf("materials with different images have the numbers %d and %d", registry.id(image1), registry.id(image2))
+	}
+
+	// The boundary of a volume is never hit, only its phase function is
+	if registry.id(green) != 0 || registry.id(unused) != 0 {
+		t.Errorf("materials that are never hit have the numbers %d and %d, want 0", registry.id(green), registry.id(unused))
+	}
+}
+
+func TestNumberObjects(t *testing.T) {
+	list := NewHittableList()
+	for z := 0; z < 5; z++ {
+		list.Add(NewSphere(NewPoint3(0, 0, float64(-3*z)), 1, NewLambertianMaterial(NewColor(1, 0, 0))))
+	}
+
+	// The BVH sorts its objects, yet they keep the number of their position in the list
+	bvh := NewBhvTree(list)
+
+	for i := range list.objects {
+		ray := NewRay(NewPoint3(0, 0, float64(2-3*i)), NewVec3(0, 0, -1), 0)
+
+		rec := HitRecord{}
+		if !bvh.Hit(ray, 0.001, 100, &rec) || rec.ObjectID != i+1 {
+			t.Errorf("object %d of the list is hit as number %d", i+1, rec.ObjectID)
+		}
+	}
+}
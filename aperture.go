@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The shape of the lens aperture, it's the shape of the out of focus highlights (bokeh)
+type Aperture struct {
+	blades   int     // Number of straight blades, 0 for a round aperture
+	rotation float64 // Rotation of the blades in radians
+	catEye   float64 // Strength of the mechanical vignetting, 0 for none
+	mask     *ApertureMask
+}
+
+// A custom aperture shape, the brightness of the mask image tells how much light passes through every point of the aperture
+type ApertureMask struct {
+	width  int
+	height int
+	cdf    []float64 // Cumulative distribution of the brightness of the pixels, row by row
+}
+
+// Creates an aperture mask from a PNG or JPEG file, the image fills the square that contains the round aperture
+func NewApertureMask(filename string) (*ApertureMask, error) {
+	texture, err := LoadImageTexture(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	mask := ApertureMask{width: texture.width, height: texture.height, cdf: make([]float64, len(texture.data)+1)}
+
+	for i, c := range texture.data {
+		mask.cdf[i+1] = mask.cdf[i] + (c.X+c.Y+c.Z)/3
+	}
+
+	total := mask.cdf[len(texture.data)]
+	if total == 0 {
+		return nil, fmt.Errorf("the aperture mask %s is black", filename)
+	}
+
+	for i := range mask.cdf {
+		mask.cdf[i] /= total
+	}
+
+	return &mask, nil
+}
+
+// Returns a random point of the mask in the [-1,1] square, with y pointing up
+func (mask *ApertureMask) sample() (float64, float64) {
+	u := RandomDouble()
+
+	// Find the pixel whose cumulative interval contains u (black pixels have empty intervals and are never chosen)
+	lo, hi := 0, len(mask.cdf)-1
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if mask.cdf[mid] <= u {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	x := (float64(lo%mask.width) + RandomDouble()) / float64(mask.width)
+	y := (float64(lo/mask.width) + RandomDouble()) / float64(mask.height)
+
+	return 2*x - 1, 1 - 2*y
+}
+
+// Returns a random point of the aperture, which fits in the unit disk
+func (aperture Aperture) sample() (float64, float64) {
+	if aperture.mask != nil {
+		return aperture.mask.sample()
+	}
+
+	if aperture.blades >= 3 {
+		// Pick one of the triangles that make up the polygon, between the center and two adjacent vertices, then a point inside it
+		n := float64(aperture.blades)
+		k := float64(RandomInt(aperture.blades))
+		a0 := aperture.rotation + 2*math.Pi*k/n
+		a1 := aperture.rotation + 2*math.Pi*(k+1)/n
+
+		r1, r2 := RandomDouble(), RandomDouble()
+		if r1+r2 > 1 {
+			r1, r2 = 1-r1, 1-r2
+		}
+
+		return r1*math.Cos(a0) + r2*math.Cos(a1), r1*math.Sin(a0) + r2*math.Sin(a1)
+	}
+
+	// Get a random point in the unit disk
+	var x, y float64
+
+	for {
+		x = RandomDoubleInInterval(-1, 1)
+		y = RandomDoubleInInterval(-1, 1)
+		if x*x+y*y <= 1 {
+			break
+		}
+	}
+
+	return x, y
+}
+
+// Mechanical vignetting: away from the image center the light that passes through the aperture is partly blocked by
+// the lens barrel, which is a circle as large as the aperture, shifted towards the image border. The point x, y of the
+// aperture is seen from the screen position sx, sy (both in [-1,1], 1 is the image corner), returns false if it's blocked.
+// The visible part of the aperture is the intersection of the two circles, which gives the "cat's eye" bokeh.
+func (aperture Aperture) passesBarrel(x, y, sx, sy float64) bool {
+	if aperture.catEye == 0 {
+		return true
+	}
+
+	dx := x - 2*aperture.catEye*sx
+	dy := y - 2*aperture.catEye*sy
+
+	return dx*dx+dy*dy <= 1
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApertureBlades(t *testing.T) {
+	SeedRandom(1)
+
+	cam := NewCamera()
+	cam.SetApertureBlades(5, 10)
+
+	// The polygon is inscribed in the unit circle, its sides are at this distance from the center
+	apothem := math.Cos(math.Pi / 5)
+
+	for i := 0; i < 10000; i++ {
+		x, y := cam.aperture.sample()
+
+		for k := 0; k < 5; k++ {
+			// Direction of the middle of side k
+			a := DegreesToRadians(10) + 2*math.Pi*(float64(k)+0.5)/5
+			if x*math.Cos(a)+y*math.Sin(a) > apothem+1e-12 {
+				t.Fatalf("the sample %g, %g is outside side %d of the pentagon", x, y, k)
+			}
+		}
+	}
+}
+
+func TestApertureMask(t *testing.T) {
+	SeedRandom(1)
+
+	// Only the left half of the aperture lets the light through
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "mask.png")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cam := NewCamera()
+	if err := cam.SetApertureMask(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		if x, y := cam.aperture.sample(); x > 0 || x < -1 || y < -1 || y > 1 {
+			t.Fatalf("the sample %g, %g is outside the white half of the mask", x, y)
+		}
+	}
+}
+
+func TestApertureMaskErrors(t *testing.T) {
+	black := filepath.Join(t.TempDir(), "black.png")
+	f, err := os.Create(black)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for _, filename := range []string{filepath.Join(t.TempDir(), "missing.png"), black} {
+		cam := NewCamera()
+		if err := cam.SetApertureMask(filename); err == nil {
+			t.Errorf("the mask %s was accepted", filename)
+		}
+		if cam.aperture.mask != nil {
+			t.Errorf("the mask %s replaced the aperture", filename)
+		}
+	}
+}
+
+func TestCatEye(t *testing.T) {
+	cam := NewCamera()
+	cam.SetCatEye(0.5)
+
+	// The image center sees the whole aperture
+	for _, point := range [][2]float64{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		if !cam.aperture.passesBarrel(point[0], point[1], 0, 0) {
+			t.Errorf("the aperture point %v is blocked at the image center", point)
+		}
+	}
+
+	// At the right border the barrel blocks the left side of the aperture
+	if !cam.aperture.passesBarrel(0.9, 0, 1, 0) {
+		t.Error("the right side of the aperture is blocked at the right border")
+	}
+	if cam.aperture.passesBarrel(-0.9, 0, 1, 0) {
+		t.Error("the left side of the aperture passes at the right border")
+	}
+}
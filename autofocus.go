@@ -0,0 +1,53 @@
+package main
+
+import "math"
+
+// Sets the focus distance to the distance of the object seen at the point x, y of the image, where 0,0 is the top left
+// corner and 1,1 is the bottom right corner (of the image of one eye, with a stereo camera). If there is no object there,
+// the focus distance is the distance of the "look at" point and the function returns false.
+// The camera parameters must be set before, as they decide what is seen at that point.
+func (camera *Camera) Autofocus(world Hittable, x, y float64) bool {
+	c := *camera
+	c.Initialize()
+
+	// Shoot a ray from the camera center (not from the lens) through the point, at the time the shutter opens
+	px, py := x*float64(c.eyeWidth), y*float64(c.eyeHeight)
+	target := c.pixelUpperLeft.Add(c.pixelDelta_U.Mul(px - 0.5)).Add(c.pixelDelta_V.Mul(py - 0.5))
+
+	origin, direction, ok := c.lookFrom, target.Sub(c.lookFrom), true
+
+	switch c.projection {
+	case ProjectionOrthographic:
+		origin, direction = target.Add(c.w.Mul(c.focusPlane)), c.w.Negate()
+	case ProjectionFisheye:
+		direction, ok = c.fisheyeDirection(px, py)
+	case ProjectionEquirectangular:
+		direction, ok = c.equirectangularDirection(px, py)
+	}
+
+	rec := HitRecord{}
+
+	if !ok || !world.Hit(NewRay(origin, direction, c.shutter.open), 0.001, math.Inf(+1), &rec) {
+		camera.focusDistance = c.lookAt.Sub(c.lookFrom).Length()
+		return false
+	}
+
+	// The focus is on a plane perpendicular to the view direction, except for the panoramic projections that focus on a sphere
+	if c.projection == ProjectionFisheye || c.projection == ProjectionEquirectangular {
+		camera.focusDistance = rec.T * direction.Length()
+	} else {
+		camera.focusDistance = rec.T * direction.Dot(c.w.Negate())
+	}
+
+	return true
+}
+
+// Sets the focus distance to the distance of the object seen at the center of the pixel at location i, j, see Autofocus
+func (camera *Camera) AutofocusOnPixel(world Hittable, i, j int) bool {
+	c := *camera
+	c.Initialize()
+
+	i, j, _ = c.eyePixel(i, j)
+
+	return camera.Autofocus(world, (float64(i)+0.5)/float64(c.eyeWidth), (float64(j)+0.5)/float64(c.eyeHeight))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAutofocus(t *testing.T) {
+	world := NewHittableList()
+	world.Add(NewSphere(NewPoint3(0, 0, -5), 1, NewLambertianMaterial(NewColor(0.5, 0.5, 0.5))))
+	world.Add(NewQuad(NewPoint3(-50, -50, -20), NewVec3(100, 0, 0), NewVec3(0, 100, 0), NewLambertianMaterial(NewColor(0.5, 0.5, 0.5))))
+
+	newCamera := func(projection int) Camera {
+		cam := NewCamera()
+		cam.SetAspectRatio(1)
+		cam.SetImageWidth(100)
+		cam.SetLookAt(NewPoint3(0, 0, -10))
+		cam.SetProjection(projection)
+		return cam
+	}
+
+	// At the image center the sphere is 4 units away
+	cam := newCamera(ProjectionPerspective)
+	if !cam.Autofocus(world, 0.5, 0.5) || math.Abs(cam.focusDistance-4) > 1e-9 {
+		t.Errorf("the focus distance at the center is %g, want 4", cam.focusDistance)
+	}
+
+	// Beside the sphere the wall is 20 units away, the focus plane is perpendicular to the view direction
+	cam = newCamera(ProjectionPerspective)
+	if !cam.Autofocus(world, 0.1, 0.1) || math.Abs(cam.focusDistance-20) > 1e-9 {
+		t.Errorf("the focus distance at the corner is %g, want 20", cam.focusDistance)
+	}
+
+	// The same for every pixel of an orthographic camera
+	cam = newCamera(ProjectionOrthographic)
+	if !cam.AutofocusOnPixel(world, 5, 5) || math.Abs(cam.focusDistance-20) > 1e-9 {
+		t.Errorf("the orthographic focus distance at the corner is %g, want 20", cam.focusDistance)
+	}
+
+	// A panoramic camera focuses on a sphere around it: the wall is 20 units away straight ahead only
+	cam = newCamera(ProjectionFisheye)
+	cam.SetVerticalFieldOfView(180)
+	initialized := cam
+	initialized.Initialize()
+	direction, _ := initialized.fisheyeDirection(20, 50)
+	if !cam.Autofocus(world, 0.2, 0.5) || math.Abs(cam.focusDistance-20/math.Abs(direction.Z)) > 1e-9 {
+		t.Errorf("the fisheye focus distance is %g, want %g", cam.focusDistance, 20/math.Abs(direction.Z))
+	}
+
+	// Behind the camera there is nothing, the focus is on the "look at" point
+	cam = newCamera(ProjectionEquirectangular)
+	cam.SetAspectRatio(2)
+	if cam.Autofocus(world, 0, 0.5) || cam.focusDistance != 10 {
+		t.Errorf("the focus distance looking backwards is %g, want 10", cam.focusDistance)
+	}
+}
+
+func TestAutofocusStereo(t *testing.T) {
+	world := NewHittableList()
+	world.Add(NewSphere(NewPoint3(0, 0, -5), 1, NewLambertianMaterial(NewColor(0.5, 0.5, 0.5))))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetImageWidth(202) // The images of the eyes are 101 pixels wide, so a pixel is at their center
+	cam.SetStereo(StereoSideBySide, 0.064)
+
+	// The center of the image of the right eye
+	if !cam.AutofocusOnPixel(world, 151, 50) || math.Abs(cam.focusDistance-4) > 1e-9 {
+		t.Errorf("the focus distance at the center of the right eye is %g, want 4", cam.focusDistance)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+)
+
+// Bounding box checks: every hit on an object must lie inside the bounding box the object reports,
+// otherwise the BVH may skip the object and parts of it go missing from the image.
+
+// Shoots random rays at an object, from all directions, and returns an error for the first hit that lies outside its bounding box
+func checkBoundingBox(object Hittable, rays int) error {
+	bbox := object.BoundingBox()
+	size := bbox.Max.Sub(bbox.Min)
+	center := bbox.Min.Add(size.Mul(0.5))
+	radius := size.Length() + 1
+
+	tolerance := 1e-6 * (1 + size.Length())
+
+	inside := func(p Point3) bool {
+		return p.X >= bbox.Min.X-tolerance && p.X <= bbox.Max.X+tolerance &&
+			p.Y >= bbox.Min.Y-tolerance && p.Y <= bbox.Max.Y+tolerance &&
+			p.Z >= bbox.Min.Z-tolerance && p.Z <= bbox.Max.Z+tolerance
+	}
+
+	for i := 0; i < rays; i++ {
+		// Aim from a random point on a sphere that surrounds the box at a random point inside the box
+		origin := center.Add(NewRandomUnitVec3().Mul(radius))
+		target := bbox.Min.Add(NewRandomVec3().MultiplyByComponent(size))
+		ray := NewRay(origin, target.Sub(origin), RandomDouble())
+
+		rec := HitRecord{}
+
+		if object.Hit(ray, 0.001, math.Inf(+1), &rec) && !inside(rec.P) {
+			return fmt.Errorf("%T hit at %v is outside its bounding box %v", object, rec.P, bbox)
+		}
+	}
+
+	return nil
+}
+
+// Checks an object and all the objects it contains, returning an error for every object with a wrong bounding box
+func checkBoundingBoxes(object Hittable, rays int) []error {
+	var errs []error
+
+	if err := checkBoundingBox(object, rays); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, child := range hittableChildren(object) {
+		errs = append(errs, checkBoundingBoxes(child, rays)...)
+	}
+
+	return errs
+}
+
+func TestBoundingBoxes(t *testing.T) {
+	rays := 1000
+	if testing.Short() {
+		rays = 100
+	}
+
+	for imageNo := range scenes {
+		imageNo := imageNo
+
+		t.Run(fmt.Sprintf("image%d", imageNo), func(t *testing.T) {
+			// Some scenes need the texture of the earth
+			if imageNo == 5 || imageNo == 23 {
+				if _, err := os.Stat("earthmap.jpg"); err != nil {
+					t.Skip("earthmap.jpg is missing")
+				}
+			}
+
+			_, world, err := buildScene(SceneIdentity{ImageNo: imageNo, Seed: 1}, 0)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, err := range checkBoundingBoxes(world, rays) {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -1,18 +1,25 @@
 package main
 
 import (
-	"math/rand"
 	"slices"
 )
 
 type BvhNode struct {
-	left  Hittable
-	right Hittable
-	bbox  Aabb
+	left    Hittable
+	right   Hittable
+	bbox    Aabb
+	leftID  int // Number of the left object in the list the BVH is built from, 0 if it's a node of the same BVH
+	rightID int
 }
 
 func NewBhvTree(list HittableList) BvhNode {
-	return NewBvhNode(list.objects) // Can also use NewBvhNodeBook(list.objects, 0, len(list.objects))
+	return NewBvhNode(list.objects) // Can also use NewBvhNodeBook(slices.Clone(list.objects), 0, len(list.objects))
+}
+
+// An object of a BVH with the number of its position in the list, for the AovObjectID output
+type bvhObject struct {
+	object Hittable
+	id     int
 }
 
 type Comparator func(a, b Hittable) int // Unlike C++'s std::sort(), comparators need to return int instead of bool
@@ -38,7 +45,17 @@ func getRandomBoxComparator(axis int) Comparator {
 }
 
 func NewBvhNode(objects []Hittable) BvhNode {
-	var left, right Hittable
+	// The objects are sorted while building the tree, so number them first as the list would
+	numbered := make([]bvhObject, len(objects))
+	for i, object := range objects {
+		numbered[i] = bvhObject{object: object, id: i + 1}
+	}
+
+	return newBvhNode(numbered)
+}
+
+func newBvhNode(objects []bvhObject) BvhNode {
+	var left, right bvhObject
 
 	if len(objects) == 1 {
 		left, right = objects[0], objects[0]
@@ -46,21 +63,23 @@ func NewBvhNode(objects []Hittable) BvhNode {
 		left, right = objects[0], objects[1]
 	} else {
 		// Split the list in half along a random axis
-		slices.SortFunc(objects, getRandomBoxComparator(rand.Intn(3)))
+		comparator := getRandomBoxComparator(RandomInt(3))
+		slices.SortFunc(objects, func(a, b bvhObject) int { return comparator(a.object, b.object) })
 
 		mid := len(objects) / 2
 
-		left, right = NewBvhNode(objects[:mid]), NewBvhNode(objects[mid:])
+		left, right = bvhObject{object: newBvhNode(objects[:mid])}, bvhObject{object: newBvhNode(objects[mid:])}
 	}
 
-	return BvhNode{left: left, right: right, bbox: left.BoundingBox().Union(right.BoundingBox())}
+	return BvhNode{left: left.object, right: right.object, bbox: left.object.BoundingBox().Union(right.object.BoundingBox()),
+		leftID: left.id, rightID: right.id}
 }
 
 // This version resembles the book's C++ code and works fine, but doesn't take advantage of Go slices
 func NewBvhNodeBook(objects []Hittable, start, end int) BvhNode {
 	var left, right Hittable
 
-	comparator := getRandomBoxComparator(rand.Intn(3))
+	comparator := getRandomBoxComparator(RandomInt(3))
 
 	objectSpan := end - start
 
@@ -95,10 +114,18 @@ func (node BvhNode) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool
 
 	if hitLeft { // Update the ray max extent as we're not interested in hits that are farther away than this
 		rayTmax = rec.T
+
+		if node.leftID != 0 { // Objects of a list inside a BVH get the number of the BVH list
+			rec.ObjectID = node.leftID
+		}
 	}
 
 	hitRight := node.right.Hit(ray, rayTmin, rayTmax, rec)
 
+	if hitRight && node.rightID != 0 {
+		rec.ObjectID = node.rightID
+	}
+
 	return hitLeft || hitRight
 }
 
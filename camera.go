@@ -1,10 +1,9 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"math"
-	"os"
 )
 
 type Camera struct {
@@ -25,6 +24,23 @@ type Camera struct {
 	samplesPerPixel int
 	maxRayDepth     int
 	background      Color // Ambient color
+	shutter         Shutter
+	projection      int
+	u, v, w         Vec3    // Camera frame basis vectors
+	focusPlane      float64 // Distance of the plane in focus, computed from focusDistance or lookAt
+	stereo          int
+	interocular     float64 // Distance between the eyes of a stereo camera
+	convergence     float64 // Distance where the views of the two eyes converge, 0 means the focus distance
+	convergePlane   float64
+	eyeWidth        int // Size of the image of one eye, it's the image size if the camera is not stereo
+	eyeHeight       int
+	aperture        Aperture
+	fStop           float64 // If set, the aperture size is computed from the f-number instead of the defocus angle
+	unitsPerMeter   float64 // Scale of the scene, used to compute the physical size of the aperture
+	aovs            []string
+	materialIDs     *materialRegistry
+	sampleClamp     float64 // Maximum brightness of a sample, 0 means no limit
+	spectral        bool    // Spectral rendering, see spectrum.go
 }
 
 func NewCamera() Camera {
@@ -39,7 +55,9 @@ func NewCamera() Camera {
 		defocusAngle:    0,
 		samplesPerPixel: 100,
 		maxRayDepth:     50,
-		background:      NewColor(0.7, 0.8, 1.0)}
+		background:      NewColor(0.7, 0.8, 1.0),
+		shutter:         NewShutter(0, 1),
+		unitsPerMeter:   1}
 }
 
 func (camera *Camera) SetAspectRatio(ratio float64) {
@@ -58,6 +76,47 @@ func (camera *Camera) SetFocusDistance(distance float64) {
 	camera.focusDistance = distance
 }
 
+// Sets the vertical field of view of a lens with the given focal length in millimeters on a full frame camera (24mm high sensor)
+func (camera *Camera) SetFocalLength(focalLength float64) {
+	camera.vfov = 2 * RadiansToDegrees(math.Atan(12/focalLength))
+}
+
+// Sets the aperture as an f-number (e.g. 2.8), the aperture diameter is the focal length divided by the f-number.
+// The focal length is the one of the field of view on a full frame camera, the scene scale converts the diameter to scene units.
+// It replaces the defocus angle.
+func (camera *Camera) SetFStop(fStop float64) {
+	camera.fStop = fStop
+}
+
+// Sets how many scene units make a meter, by default the scene is in meters
+func (camera *Camera) SetSceneScale(unitsPerMeter float64) {
+	camera.unitsPerMeter = unitsPerMeter
+}
+
+// Sets a polygonal aperture made of the given number of straight blades, rotated by the given angle in degrees
+func (camera *Camera) SetApertureBlades(blades int, rotation float64) {
+	camera.aperture.blades = blades
+	camera.aperture.rotation = DegreesToRadians(rotation)
+}
+
+// Sets a custom aperture shape from a PNG or JPEG file, the image fills the square that contains the round aperture.
+// Returns an error if the file can't be loaded, the aperture is unchanged.
+func (camera *Camera) SetApertureMask(filename string) error {
+	mask, err := NewApertureMask(filename)
+	if err != nil {
+		return err
+	}
+
+	camera.aperture.mask = mask
+	return nil
+}
+
+// Sets the strength of the mechanical vignetting, which darkens the image borders and gives the out of focus highlights
+// a "cat's eye" shape there: 0 is none, at 0.5 the image corners only get light from half the aperture width
+func (camera *Camera) SetCatEye(strength float64) {
+	camera.aperture.catEye = strength
+}
+
 func (camera *Camera) SetImageWidth(width int) {
 	camera.imageWidth = width
 }
@@ -75,12 +134,85 @@ func (camera *Camera) SetRenderingParams(samplesPerPixel, maxRayDepth int) {
 	camera.maxRayDepth = maxRayDepth
 }
 
+func (camera *Camera) SetSamplesPerPixel(samplesPerPixel int) {
+	camera.samplesPerPixel = samplesPerPixel
+}
+
+// Sets the scene times when the shutter opens and closes, by default it's open from time 0 to time 1
+func (camera *Camera) SetShutter(open, close float64) {
+	camera.shutter.open = open
+	camera.shutter.close = close
+}
+
+// Sets how the shutter opens and closes, either ShutterBox or ShutterTriangle
+func (camera *Camera) SetShutterCurve(curve int) {
+	camera.shutter.curve = curve
+}
+
+// Sets a custom shutter curve, which tells how much the shutter is open at every point x in [0,1] of the exposure
+func (camera *Camera) SetCustomShutterCurve(curve func(x float64) float64) {
+	camera.shutter.curve = ShutterCustom
+	camera.shutter.cdf = newShutterCDF(curve)
+}
+
+// Simulates a rolling shutter, where the exposure of every scanline starts later than the one above:
+// the exposure of the bottom scanline starts readout time units after the exposure of the top one
+func (camera *Camera) SetRollingShutter(readout float64) {
+	camera.shutter.readout = readout
+}
+
 func (camera *Camera) SetVerticalFieldOfView(vfov float64) {
 	camera.vfov = vfov
 }
 
+// Sets how directions are mapped to the image, the default is ProjectionPerspective
+func (camera *Camera) SetProjection(projection int) {
+	camera.projection = projection
+}
+
+// Renders the images of two eyes, placed according to the stereo layout, the eyes are the given distance apart
+// (e.g. 0.064 if the scene is in meters). With the equirectangular projection the camera is omni-directional stereo.
+func (camera *Camera) SetStereo(layout int, interocularDistance float64) {
+	camera.stereo = layout
+	camera.interocular = interocularDistance
+}
+
+// Sets the distance where the views of the two eyes converge, by default it's the focus distance
+func (camera *Camera) SetConvergenceDistance(distance float64) {
+	camera.convergence = distance
+}
+
+// Limits the brightness of every sample, to avoid fireflies (isolated bright pixels), 0 means no limit
+func (camera *Camera) SetSampleClamp(clamp float64) {
+	camera.sampleClamp = clamp
+}
+
+// Renders with wavelengths instead of RGB colors, every camera ray carries three random wavelengths (see spectrum.go)
+func (camera *Camera) SetSpectral(spectral bool) {
+	camera.spectral = spectral
+}
+
+// Records the given auxiliary outputs (e.g. AovDepth, AovNormal) as layers of the film, alongside the color
+func (camera *Camera) SetAovs(names ...string) {
+	camera.aovs = names
+}
+
+// Numbers the materials of the world for the AovMaterialID output, the world must be complete
+func (camera *Camera) NumberMaterials(world Hittable) {
+	camera.materialIDs = newMaterialRegistry(world)
+}
+
 func (camera *Camera) Initialize() {
-	camera.imageHeight = int(float64(camera.imageWidth) / camera.aspectRatio)
+	// With a stereo camera the aspect ratio is the one of each eye, the images of the two eyes are placed side by side or one above the other
+	camera.eyeWidth = camera.imageWidth
+	if camera.stereo == StereoSideBySide {
+		camera.eyeWidth = camera.imageWidth / 2
+	}
+	camera.eyeHeight = int(float64(camera.eyeWidth) / camera.aspectRatio)
+	camera.imageHeight = camera.eyeHeight
+	if camera.stereo == StereoTopBottom {
+		camera.imageHeight = 2 * camera.eyeHeight
+	}
 
 	// Determine the viewport dimentions
 	focusDistance := camera.focusDistance
@@ -90,20 +222,26 @@ func (camera *Camera) Initialize() {
 	theta := DegreesToRadians(camera.vfov)
 	h := math.Tan(theta / 2)
 	viewportHeight := h * 2 * focusDistance
-	viewportWidth := viewportHeight * float64(camera.imageWidth) / float64(camera.imageHeight)
+	viewportWidth := viewportHeight * float64(camera.eyeWidth) / float64(camera.eyeHeight)
 
 	// Calculate the u,v,w unit basis vectors for the camera coordinate frame.
 	w := camera.lookFrom.Sub(camera.lookAt).UnitVector()
 	u := camera.vUp.Cross(w).UnitVector()
 	v := w.Cross(u)
+	camera.u, camera.v, camera.w = u, v, w
+	camera.focusPlane = focusDistance
+	camera.convergePlane = camera.convergence
+	if camera.convergePlane == 0 {
+		camera.convergePlane = focusDistance
+	}
 
 	// The viewport U and V vectors have the same alignment as the image we want to produce, which has the (0,0) pixel at the top left
 	viewport_U := u.Mul(viewportWidth)   // Vector from left to right edge of viewport
 	viewport_V := v.Mul(-viewportHeight) // Vector from top to bottom edge of viewport
 
 	// The pixel delta vectors represent the distance between adjacent pixels in the viewport
-	camera.pixelDelta_U = viewport_U.Div(float64(camera.imageWidth))
-	camera.pixelDelta_V = viewport_V.Div(float64(camera.imageHeight))
+	camera.pixelDelta_U = viewport_U.Div(float64(camera.eyeWidth))
+	camera.pixelDelta_V = viewport_V.Div(float64(camera.eyeHeight))
 
 	// The viewport is positioned along the negative Z-axis, at the "focal length" distance from the camera, centered with respect to the X and Y axis
 	viewportUpperLeft := camera.lookFrom.Sub(w.Mul(focusDistance)).Sub(viewport_U.Div(2)).Sub(viewport_V.Div(2))
@@ -113,6 +251,10 @@ func (camera *Camera) Initialize() {
 
 	// Calculate the camera defocus disk basis vectors
 	defocusRadius := camera.focusDistance * math.Tan(DegreesToRadians(camera.defocusAngle/2))
+	if camera.fStop > 0 {
+		focalLength := 12 / h // In millimeters, on a full frame camera
+		defocusRadius = focalLength / camera.fStop / 2 / 1000 * camera.unitsPerMeter
+	}
 	camera.defocusDisk_U = u.Mul(defocusRadius)
 	camera.defocusDisk_V = v.Mul(defocusRadius)
 }
@@ -128,35 +270,72 @@ func (camera Camera) getRandomPointInPixelSquare() Vec3 {
 	return camera.pixelDelta_U.Mul(px).Add(camera.pixelDelta_V.Mul(py))
 }
 
-func (camera Camera) getRandomPointInDefocusDisk() Point3 {
-	// Get a random point in the unit disk
-	var x, y float64
+// Returns true if the camera has a lens with an aperture, otherwise it's a pinhole camera
+func (camera Camera) hasAperture() bool {
+	return camera.defocusAngle > 0 || camera.fStop > 0
+}
+
+// Returns a random point in the lens aperture centered at the given camera center, the pixel at location i, j
+// is used for vignetting: returns false if the point is blocked by the lens barrel
+func (camera Camera) getRandomPointInAperture(center Point3, i, j int) (Point3, bool) {
+	x, y := camera.aperture.sample()
 
-	for {
-		x = RandomDoubleInInterval(-1, 1)
-		y = RandomDoubleInInterval(-1, 1)
-		if x*x+y*y <= 1 {
-			break
+	if camera.aperture.catEye > 0 {
+		// Position of the pixel relative to the image center, it's 1 at the corners
+		halfDiagonal := math.Hypot(float64(camera.eyeWidth), float64(camera.eyeHeight)) / 2
+		sx := (float64(i) + 0.5 - float64(camera.eyeWidth)/2) / halfDiagonal
+		sy := (float64(camera.eyeHeight)/2 - float64(j) - 0.5) / halfDiagonal
+
+		if !camera.aperture.passesBarrel(x, y, sx, sy) {
+			return Point3{}, false
 		}
 	}
 
 	// Return the corresponding point in the defocus disk
-	return camera.lookFrom.Add(camera.defocusDisk_U.Mul(x)).Add(camera.defocusDisk_V.Mul(y))
+	return center.Add(camera.defocusDisk_U.Mul(x)).Add(camera.defocusDisk_V.Mul(y)), true
 }
 
-// Get a randomly sampled camera ray for the pixel at location i, j
-func (camera Camera) getRay(i, j int) Ray {
+// Get a randomly sampled camera ray for the pixel at location i, j, returns false if the pixel is outside the image
+// of the projection (e.g. outside the circle of a fisheye) or if the ray is blocked by the lens.
+// In spectral mode the ray carries random wavelengths.
+func (camera Camera) getRay(i, j int) (Ray, bool) {
+	ray, ok := camera.getGeometricRay(i, j)
+
+	if ok && camera.spectral {
+		ray = ray.WithHeroWavelength(SampleWavelength())
+	}
+
+	return ray, ok
+}
+
+// Same as getRay, without the wavelengths
+func (camera Camera) getGeometricRay(i, j int) (Ray, bool) {
+	i, j, eye := camera.eyePixel(i, j)
+
+	switch camera.projection {
+	case ProjectionOrthographic:
+		return camera.getOrthographicRay(i, j, eye), true
+	case ProjectionFisheye, ProjectionEquirectangular:
+		return camera.getPanoramicRay(i, j, eye)
+	}
+
 	pixelCenter := camera.pixelUpperLeft.Add(camera.pixelDelta_U.Mul(float64(i))).Add(camera.pixelDelta_V.Mul(float64(j)))
 	pixelSample := pixelCenter.Add(camera.getRandomPointInPixelSquare())
 
 	origin := camera.lookFrom
-	if camera.defocusAngle > 0 {
-		origin = camera.getRandomPointInDefocusDisk()
+	if eye != 0 {
+		origin, pixelSample = camera.stereoViewport(pixelSample, eye)
+	}
+	if camera.hasAperture() {
+		var ok bool
+		if origin, ok = camera.getRandomPointInAperture(origin, i, j); !ok {
+			return Ray{}, false
+		}
 	}
 	direction := pixelSample.Sub(origin) // Note: the direction is not normalized
-	time := RandomDouble()
+	time := camera.shutter.sampleTime(j, camera.eyeHeight)
 
-	return NewRay(origin, direction, time)
+	return NewRay(origin, direction, time), true
 }
 
 // The following function uses the properties of the object material to properly compute the ray color
@@ -167,56 +346,147 @@ func (camera Camera) RayColor(ray Ray, world Hittable, depth int) Color {
 		return Color{0, 0, 0}
 	}
 
+	raysTraced.Add(1)
+
 	if world.Hit(ray, 0.001, math.Inf(+1), &rec) {
-		scattered := Ray{}
-		attenuation := Color{}
-		color := rec.Mat.Emitted(rec.U, rec.V, rec.P)
+		color, _ := camera.shadeHit(ray, &rec, world, depth)
+		return color
+	}
 
-		if rec.Mat.Scatter(ray, &rec, &attenuation, &scattered) {
-			c := camera.RayColor(scattered, world, depth-1)
-			color = color.Add(c.MultiplyByComponent(attenuation))
-		}
+	return camera.backgroundColor(ray)
+}
 
-		return color
+// Returns the background color, as a spectrum for spectral rays
+func (camera Camera) backgroundColor(ray Ray) Color {
+	if ray.IsSpectral() {
+		return RGBToSpectrum(camera.background, ray.Wavelengths())
 	}
 
 	return camera.background
 }
 
-func (camera *Camera) Render(w io.Writer, world Hittable) {
-	camera.Initialize()
+// Returns the color of a ray that hit a surface, and the attenuation of the surface (black if it absorbed the ray)
+func (camera Camera) shadeHit(ray Ray, rec *HitRecord, world Hittable, depth int) (Color, Color) {
+	scattered := Ray{}
+	attenuation := Color{}
+	color := emitted(rec.Mat, ray, rec)
 
-	fmt.Fprintf(w, "P3\n") // Magic
-	fmt.Fprintf(w, "%d %d\n", camera.imageWidth, camera.imageHeight)
-	fmt.Fprintf(w, "255\n") // Maximum value of a color component
+	if !scatter(rec.Mat, ray, rec, &attenuation, &scattered) {
+		return color, Color{}
+	}
 
-	for y := 0; y < camera.imageHeight; y++ {
-		fmt.Fprintf(os.Stderr, "Rendering scanline %d of %d (%d%%)\n", y+1, camera.imageHeight, (y+1)*100/camera.imageHeight)
+	c := camera.RayColor(scattered, world, depth-1)
+
+	return color.Add(c.MultiplyByComponent(attenuation)), attenuation
+}
 
-		for x := 0; x < camera.imageWidth; x++ {
-			c := NewColor(0, 0, 0) // Start with black
+// Returns the color of a random sample of the pixel at location i, j, pixels outside the image of the projection are black
+func (camera Camera) SampleColor(world Hittable, i, j int) Color {
+	ray, ok := camera.getRay(i, j)
+
+	if !ok {
+		return Color{0, 0, 0}
+	}
+
+	return filmColor(ray, camera.RayColor(ray, world, camera.maxRayDepth))
+}
+
+// Adds a random sample of the pixel at location i, j to the pixel x, y of the film, with the auxiliary outputs if they are enabled
+func (camera Camera) addSample(world Hittable, film *Film, x, y, i, j int) {
+	if len(camera.aovs) == 0 {
+		film.AddSample(x, y, clampSample(camera.SampleColor(world, i, j), camera.sampleClamp))
+		return
+	}
+
+	c, aov := camera.SampleAovs(world, i, j)
+	aov.Color = clampSample(c, camera.sampleClamp)
+
+	film.AddSample(x, y, aov.Color)
+	film.AddAovSample(x, y, camera.aovs, aov)
+}
+
+// Adds the given number of samples to every pixel of a scanline
+func (camera *Camera) RenderScanline(world Hittable, film *Film, y, samples int) {
+	for x := 0; x < camera.imageWidth; x++ {
+		for sample := 0; sample < samples; sample++ {
+			camera.addSample(world, film, x, y, x, y)
+		}
+	}
+}
 
-			// Accumulate all samples into one color, this may bring the color components out of their nominal [0,1] range
-			for sample := 0; sample < camera.samplesPerPixel; sample++ {
-				ray := camera.getRay(x, y)
-				rc := camera.RayColor(ray, world, camera.maxRayDepth)
-				c = c.Add(rc)
+// Adds the given number of samples to every pixel of a tile of the image, the top left corner of the tile is at x0, y0
+// and the tile size is the size of the film
+func (camera *Camera) RenderTile(world Hittable, tile *Film, x0, y0, samples int) {
+	for y := 0; y < tile.Height; y++ {
+		for x := 0; x < tile.Width; x++ {
+			for sample := 0; sample < samples; sample++ {
+				camera.addSample(world, tile, x, y, x0+x, y0+y)
 			}
+		}
+	}
+}
+
+// Renders the film one scanline at a time, checking the context and reporting progress between scanlines
+func (camera *Camera) renderPass(ctx context.Context, world Hittable, film *Film, samples int, tracker *progressTracker) error {
+	tracker.startPass()
+
+	for y := 0; y < camera.imageHeight; y++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		camera.RenderScanline(world, film, y, samples)
 
-			// Bring the color components back to the [0,1] range
-			c = c.Div(float64(camera.samplesPerPixel))
-			// Note: because of the lights, it's possible that some color components are still greater than 1,
-			// this will be taken care of in the LinearToRGB() function
+		tracker.update(camera.imageWidth, int64(camera.imageWidth*samples))
+	}
 
-			// Apply gamma correction and convert to the standard RGB range
-			ir := LinearToRGB(c.X)
-			ig := LinearToRGB(c.Y)
-			ib := LinearToRGB(c.Z)
+	return nil
+}
+
+// Adds the given number of samples to every pixel of the film, the camera must have been initialized.
+// If the context is cancelled the render stops and returns the context error, the film holds the scanlines rendered so far.
+// If progress is not nil, it's called after every scanline.
+func (camera *Camera) RenderContext(ctx context.Context, world Hittable, film *Film, samples int, progress ProgressFunc) error {
+	pixels := camera.imageWidth * camera.imageHeight
+	tracker := newProgressTracker(1, pixels, int64(pixels*samples), progress)
+
+	return camera.renderPass(ctx, world, film, samples, tracker)
+}
 
-			fmt.Fprintf(w, "%d %d %d\n", ir, ig, ib)
+// Renders a crop window of the image into a film as large as the window, with the top left corner of the window at x0, y0.
+// The camera geometry is the one of the whole image, so the pixels match the pixels of a full render.
+func (camera *Camera) RenderCrop(ctx context.Context, world Hittable, film *Film, x0, y0, samples int, progress ProgressFunc) error {
+	pixels := film.Width * film.Height
+	tracker := newProgressTracker(1, pixels, int64(pixels*samples), progress)
+
+	film.AddLayers(camera.aovs)
+	tracker.startPass()
+
+	for y := 0; y < film.Height; y++ {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		fmt.Fprintln(w)
+
+		// A film made of one row of the crop film, sharing its data
+		camera.RenderTile(world, film.Rows(y, 1), x0, y0+y, samples)
+
+		tracker.update(film.Width, int64(film.Width*samples))
 	}
 
-	fmt.Fprintln(w)
+	return nil
+}
+
+// Renders the whole image into a new film
+func (camera *Camera) RenderFilm(world Hittable) *Film {
+	camera.Initialize()
+
+	film := NewFilm(camera.imageWidth, camera.imageHeight)
+
+	camera.RenderContext(context.Background(), world, film, camera.samplesPerPixel, nil)
+
+	return film
+}
+
+func (camera *Camera) Render(w io.Writer, world Hittable) {
+	camera.RenderFilm(world).WritePPM(w)
 }
@@ -0,0 +1,134 @@
+package main
+
+// A material covered by a layer of clear varnish (a dielectric coat), e.g. car paint or lacquered wood. The coat reflects
+// the light according to its Fresnel reflectance, the rest goes through and is scattered by the base material.
+// The coat doesn't absorb light and is thin, so the base sees the same rays. The light scattered by the base goes
+// through the coat again on its way out, and the part that the coat reflects back inside bounces on the base until it
+// leaves, which is approximated by a constant factor, so that the material stays reciprocal.
+type CoatedMaterial struct {
+	base      Material
+	ir        float64
+	roughness Texture // Perceptual roughness of the coat, from the first component of the texture: 0 is a mirror
+	inside    float64 // Fraction of the light scattered by a white diffuse base that the coat reflects back inside
+}
+
+func NewCoatedMaterial(base Material, indexOfRefraction, roughness float64) CoatedMaterial {
+	return NewTextureCoatedMaterial(base, indexOfRefraction, NewSolidColorTexture(NewColor(roughness, roughness, roughness)))
+}
+
+func NewTextureCoatedMaterial(base Material, indexOfRefraction float64, roughness Texture) CoatedMaterial {
+	return CoatedMaterial{base: base, ir: indexOfRefraction, roughness: roughness, inside: diffuseFresnel(indexOfRefraction)}
+}
+
+// Returns the average Fresnel reflectance of a dielectric for light coming from all the directions with a cosine
+// distribution, the integral of 2 F(cos) cos over the cosines
+func diffuseFresnel(eta float64) float64 {
+	const steps = 1000
+	sum := 0.0
+
+	for i := 0; i < steps; i++ {
+		cosTheta := (float64(i) + 0.5) / steps
+		sum += 2 * FresnelDielectric(cosTheta, eta) * cosTheta / steps
+	}
+
+	return sum
+}
+
+// Samples the reflection of a coat with the given strength in [0,1]. Returns true if the coat reflects the ray, with the
+// weight of the reflection and the reflected direction in the local frame of the surface (wo is the direction towards
+// the viewer), or false if the ray goes through the coat. The weight is 0 if the microfacets block the reflected ray.
+func sampleCoat(wo Vec3, ggx Ggx, indexOfRefraction, strength float64) (float64, Vec3, bool) {
+	h := ggx.SampleVisibleNormal(wo)
+
+	if RandomDouble() >= strength*FresnelDielectric(wo.Dot(h), indexOfRefraction) {
+		return 0, Vec3{}, false
+	}
+
+	wi := Reflect(wo.Negate(), h)
+
+	if wi.Z <= 0 {
+		return 0, wi, true
+	}
+
+	return ggx.G2(wo, wi) / ggx.G1(wo), wi, true
+}
+
+// Samples the reflection of the coat, returns true if the coat reflected the ray, with the weight of the reflection
+func (m CoatedMaterial) reflect(ray Ray, rec *HitRecord, scattered *Ray) (float64, bool) {
+	// The coat is only on the outside
+	if !rec.FrontFace {
+		return 0, false
+	}
+
+	onb := NewOnb(rec.Normal)
+	wo := onb.ToLocal(ray.Direction().UnitVector().Negate())
+
+	if wo.Z <= 0 {
+		return 0, false
+	}
+
+	weight, wi, reflected := sampleCoat(wo, NewGgx(m.roughness.Value(rec.U, rec.V, rec.P).X, 0), m.ir, 1)
+
+	if reflected {
+		*scattered = ray.Bounce(rec.P, onb.ToWorld(wi))
+	}
+
+	return weight, reflected
+}
+
+// Weights the light scattered by the base by the fraction that leaves through the coat, so that the base is weighted
+// by the light that goes through the coat both ways, whatever the direction of the light. The light reflected back
+// inside leaves after more bounces, it's added back as if the base were white and diffuse.
+func (m CoatedMaterial) leave(rec *HitRecord, scattered Ray, attenuation *Color) {
+	leaveCoat(rec, scattered, attenuation, m.ir, 1, m.inside)
+}
+
+// Weights the light scattered below a coat with the given strength in [0,1] by the fraction that leaves through it,
+// inside is the diffuseFresnel of the coat, see CoatedMaterial.leave
+func leaveCoat(rec *HitRecord, scattered Ray, attenuation *Color, indexOfRefraction, strength, inside float64) {
+	if !rec.FrontFace {
+		return
+	}
+
+	if cosTheta := scattered.Direction().UnitVector().Dot(rec.Normal); cosTheta > 0 {
+		*attenuation = attenuation.Mul((1 - strength*FresnelDielectric(cosTheta, indexOfRefraction)) / (1 - strength*inside))
+	}
+}
+
+func (m CoatedMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	if weight, reflected := m.reflect(ray, rec, scattered); reflected {
+		*attenuation = Color{weight, weight, weight}
+		return weight > 0
+	}
+
+	if !m.base.Scatter(ray, rec, attenuation, scattered) {
+		return false
+	}
+
+	m.leave(rec, *scattered, attenuation)
+
+	return true
+}
+
+func (m CoatedMaterial) ScatterSpectral(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	if weight, reflected := m.reflect(ray, rec, scattered); reflected {
+		*attenuation = Color{weight, weight, weight}
+		return weight > 0
+	}
+
+	if !scatter(m.base, ray, rec, attenuation, scattered) {
+		return false
+	}
+
+	m.leave(rec, *scattered, attenuation)
+
+	return true
+}
+
+func (m CoatedMaterial) Emitted(u, v float64, p Point3) Color {
+	return m.base.Emitted(u, v, p)
+}
+
+func (m CoatedMaterial) EmittedSpectral(u, v float64, p Point3, wavelengths Vec3) Color {
+	return emittedSpectral(m.base, u, v, p, wavelengths)
+}
@@ -0,0 +1,116 @@
+package main
+
+// The complex index of refraction of a conductor, for the red, green and blue wavelengths
+type ConductorIOR struct {
+	Eta Color // Real part, the usual index of refraction
+	K   Color // Imaginary part, the extinction coefficient: how fast the light is absorbed inside the metal
+
+	// Optional values for every wavelength, used by spectral rays. If they are nil, spectral rays interpolate Eta and K.
+	SpectralEta, SpectralK Spectrum
+}
+
+// Builds the index of refraction of a conductor from values measured at the given wavelengths, in increasing order
+func NewMeasuredConductorIOR(wavelengths, eta, k []float64) ConductorIOR {
+	spectralEta, spectralK := NewSampledSpectrum(wavelengths, eta), NewSampledSpectrum(wavelengths, k)
+
+	return ConductorIOR{
+		Eta:         NewColor(spectralEta.At(650), spectralEta.At(550), spectralEta.At(450)),
+		K:           NewColor(spectralK.At(650), spectralK.At(550), spectralK.At(450)),
+		SpectralEta: spectralEta,
+		SpectralK:   spectralK}
+}
+
+// Measured indices of refraction of some metals, at 650, 550 and 450 nm
+var (
+	Gold      = ConductorIOR{Eta: NewColor(0.143, 0.374, 1.442), K: NewColor(3.983, 2.385, 1.603)}
+	Copper    = ConductorIOR{Eta: NewColor(0.200, 0.924, 1.102), K: NewColor(3.912, 2.452, 2.142)}
+	Aluminium = ConductorIOR{Eta: NewColor(1.657, 0.880, 0.521), K: NewColor(9.224, 6.270, 4.837)}
+	Silver    = ConductorIOR{Eta: NewColor(0.155, 0.117, 0.138), K: NewColor(4.828, 3.122, 2.147)}
+)
+
+// Reflectance for light that hits the conductor at an angle with the given cosine
+func (ior ConductorIOR) Fresnel(cosTheta float64) Color {
+	return NewColor(
+		FresnelConductor(cosTheta, ior.Eta.X, ior.K.X),
+		FresnelConductor(cosTheta, ior.Eta.Y, ior.K.Y),
+		FresnelConductor(cosTheta, ior.Eta.Z, ior.K.Z))
+}
+
+// Reflectance at the three wavelengths of a spectral ray
+func (ior ConductorIOR) FresnelSpectral(cosTheta float64, wavelengths Vec3) Color {
+	eta, k := ior.SpectralEta, ior.SpectralK
+
+	if eta == nil || k == nil {
+		eta = NewSampledSpectrum([]float64{450, 550, 650}, []float64{ior.Eta.Z, ior.Eta.Y, ior.Eta.X})
+		k = NewSampledSpectrum([]float64{450, 550, 650}, []float64{ior.K.Z, ior.K.Y, ior.K.X})
+	}
+
+	return NewColor(
+		FresnelConductor(cosTheta, eta.At(wavelengths.X), k.At(wavelengths.X)),
+		FresnelConductor(cosTheta, eta.At(wavelengths.Y), k.At(wavelengths.Y)),
+		FresnelConductor(cosTheta, eta.At(wavelengths.Z), k.At(wavelengths.Z)))
+}
+
+// A conductor (metal) material with a GGX microfacet surface. Unlike the fuzz of MetalMaterial, which absorbs the rays it
+// pushes below the surface, the roughness only loses the light that bounces more than once between the microfacets
+// (which only matters on very rough surfaces, that look a bit darker than they should). The color of the metal comes
+// from its index of refraction, so it changes at grazing angles as in real metals.
+type ConductorMaterial struct {
+	BlackEmitter
+	ior        ConductorIOR
+	roughness  Texture // Perceptual roughness in [0,1], from the first component of the texture: 0 is a perfect mirror
+	anisotropy float64 // In [-1,1], 0 is isotropic. Positive values stretch the highlights along the U direction of the surface (see HitRecord.Tangent), negative values across it.
+}
+
+func NewConductorMaterial(ior ConductorIOR, roughness float64) ConductorMaterial {
+	return NewTextureConductorMaterial(ior, NewSolidColorTexture(NewColor(roughness, roughness, roughness)))
+}
+
+func NewTextureConductorMaterial(ior ConductorIOR, roughness Texture) ConductorMaterial {
+	return ConductorMaterial{ior: ior, roughness: roughness}
+}
+
+func NewAnisotropicConductorMaterial(ior ConductorIOR, roughness, anisotropy float64) ConductorMaterial {
+	m := NewConductorMaterial(ior, roughness)
+	m.anisotropy = anisotropy
+	return m
+}
+
+func (m ConductorMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	return m.scatter(ray, rec, attenuation, scattered, m.ior.Fresnel)
+}
+
+func (m ConductorMaterial) ScatterSpectral(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	fresnel := func(cosTheta float64) Color {
+		return m.ior.FresnelSpectral(cosTheta, ray.Wavelengths())
+	}
+
+	return m.scatter(ray, rec, attenuation, scattered, fresnel)
+}
+
+func (m ConductorMaterial) scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray, fresnel func(cosTheta float64) Color) bool {
+	onb := NewTangentOnb(rec.Normal, rec.Tangent)
+	wo := onb.ToLocal(ray.Direction().UnitVector().Negate())
+
+	if wo.Z <= 0 {
+		return false
+	}
+
+	ggx := NewGgx(m.roughness.Value(rec.U, rec.V, rec.P).X, m.anisotropy)
+
+	// Reflect on a microfacet the incident ray can see
+	h := ggx.SampleVisibleNormal(wo)
+	wi := Reflect(wo.Negate(), h)
+
+	// The microfacet can reflect the ray below the surface, where other microfacets block it
+	if wi.Z <= 0 {
+		return false
+	}
+
+	// With visible normal sampling, the weight of the sample is the Fresnel reflectance times the fraction of
+	// reflected light that isn't blocked by the other microfacets
+	*attenuation = fresnel(wo.Dot(h)).Mul(ggx.G2(wo, wi) / ggx.G1(wo))
+	*scattered = ray.Bounce(rec.P, onb.ToWorld(wi))
+
+	return true
+}
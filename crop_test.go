@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// A texture whose color is the position of the point, so every pixel of an image of it has a different color
+type positionTexture struct{}
+
+func (positionTexture) Value(u, v float64, p Point3) Color {
+	return Color(p)
+}
+
+func TestRenderCrop(t *testing.T) {
+	// A light with the color of its position fills the view, so the color of a pixel only depends on where it looks
+	world := NewHittableList()
+	world.Add(NewQuad(NewPoint3(-10, -10, -1), NewVec3(20, 0, 0), NewVec3(0, 20, 0), NewDiffuseLight(positionTexture{})))
+
+	cam := NewCamera()
+	cam.SetImageWidth(40)
+	cam.SetAspectRatio(2)
+	cam.Initialize()
+
+	const samples = 64
+
+	SeedRandom(1)
+	full := NewFilm(cam.imageWidth, cam.imageHeight)
+	if err := cam.RenderContext(context.Background(), world, full, samples, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	SeedRandom(2)
+	crop := NewFilm(6, 4)
+	if err := cam.RenderCrop(context.Background(), world, crop, 30, 13, samples, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pixels are 0.1 units apart, the samples of a pixel average to its center
+	for y := 0; y < crop.Height; y++ {
+		for x := 0; x < crop.Width; x++ {
+			if got, want := crop.Pixel(x, y), full.Pixel(30+x, 13+y); crop.Count[x+y*crop.Width] != samples || !nearVec3(got, want, 0.03) {
+				t.Errorf("pixel %d,%d of the crop is %v, want %v as pixel %d,%d of the image", x, y, got, want, 30+x, 13+y)
+			}
+		}
+	}
+}
+
+func TestComposite(t *testing.T) {
+	dir := t.TempDir()
+	render := func(options Options) error {
+		options.seed, options.samplesPerPixel = 1, 1
+		return run(2, options)
+	}
+
+	// A .film gets the samples of the crop, which must have the same robust mean groups
+	output := filepath.Join(dir, "out.film")
+	if err := render(Options{output: output, sampleSeed: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := render(Options{output: output, sampleSeed: 2, crop: "10,20,4,3", composite: true, robust: 3}); err == nil {
+		t.Errorf("a crop with robust mean groups was pasted into a film without them")
+	}
+
+	if err := run(2, Options{output: output, seed: 2, sampleSeed: 2, samplesPerPixel: 2, crop: "10,20,4,3", composite: true}); err == nil {
+		t.Errorf("a crop of another scene was pasted into the film")
+	}
+
+	if err := run(2, Options{output: output, seed: 1, sampleSeed: 2, samplesPerPixel: 2, crop: "10,20,4,3", composite: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	film, err := ReadFilm(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pixel := range []struct{ x, y, samples int }{{10, 20, 2}, {13, 22, 2}, {9, 20, 1}, {14, 22, 1}, {10, 23, 1}} {
+		if count := film.Count[pixel.x+pixel.y*film.Width]; count != pixel.samples {
+			t.Errorf("pixel %d,%d of the composite has %d samples, want %d", pixel.x, pixel.y, count, pixel.samples)
+		}
+	}
+
+	if len(film.SampleSeeds) != 2 {
+		t.Errorf("the composite has the sample seeds %v, want the ones of both renders", film.SampleSeeds)
+	}
+
+	// An image only keeps the colors, so a crop with robust mean groups is pasted as colors
+	output = filepath.Join(dir, "out.pfm")
+	if err := render(Options{output: output, sampleSeed: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := render(Options{output: output, sampleSeed: 2, crop: "10,20,4,3", composite: true, robust: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := ReadImage(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range image.Sum {
+		if math.IsNaN(c.X) || c.X < 0 {
+			t.Fatalf("the composite image has the color %v", c)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import "math"
+
+// The auxiliary outputs the denoiser needs
+var DenoiseAovs = []string{AovAlbedo, AovNormal, AovDepth, AovVariance}
+
+// Denoising parameters, how much the guide buffers must differ to stop the filter
+const (
+	denoiseNormalPower = 64   // Exponent of the cosine between the normals
+	denoiseDepth       = 0.01 // Relative depth difference per pixel of distance
+	denoiseLuminance   = 16   // Luminance difference, in standard deviations of the noise
+)
+
+func luminance(c Color) float64 {
+	return 0.2126*c.X + 0.7152*c.Y + 0.0722*c.Z
+}
+
+// Removes the noise of a film with an edge-avoiding à-trous wavelet filter: the image is blurred with a 5x5 kernel
+// whose taps get farther apart at every iteration (1, 2, 4... pixels), but the taps only count as much as they look like
+// the center pixel: same normal, same depth and a color difference that can be explained by the noise.
+// The noise is estimated from the per-pixel variance, which shrinks as the filter proceeds, so clean areas are left alone.
+// The film must have the layers of DenoiseAovs. Textures stay sharp as the filter works on the color divided by the albedo.
+func Denoise(film *Film, iterations int) *Film {
+	w, h := film.Width, film.Height
+	n := w * h
+
+	albedo := make([]Color, n)
+	normal := make([]Vec3, n)
+	depth := make([]float64, n)
+	irradiance := make([]Color, n)
+	variance := make([]float64, n) // Luminance variance of the irradiance estimate
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			o := x + y*w
+
+			albedo[o] = film.LayerPixel(AovAlbedo, x, y)
+			normal[o] = film.LayerPixel(AovNormal, x, y)
+			depth[o] = film.LayerPixel(AovDepth, x, y).X
+
+			a := NewColor(math.Max(albedo[o].X, 0.01), math.Max(albedo[o].Y, 0.01), math.Max(albedo[o].Z, 0.01))
+			c := film.Pixel(x, y)
+			irradiance[o] = NewColor(c.X/a.X, c.Y/a.Y, c.Z/a.Z)
+
+			// The variance of the average is the variance of the samples divided by their number
+			if film.Count[o] > 0 {
+				la := luminance(a)
+				variance[o] = luminance(film.LayerPixel(AovVariance, x, y)) / float64(film.Count[o]) / (la * la)
+			}
+		}
+	}
+
+	// With few samples the variance of a pixel is unreliable (e.g. it's 0 if no sample found the light), the variance of the
+	// neighborhood is a better estimate
+	variance = blurVariance(variance, depth, w, h, 3)
+
+	kernel := [5]float64{1.0 / 16, 1.0 / 4, 3.0 / 8, 1.0 / 4, 1.0 / 16}
+
+	next := make([]Color, n)
+	nextVariance := make([]float64, n)
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		step := 1 << iteration
+		smoothVariance := blurVariance(variance, depth, w, h, 1)
+
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				o := x + y*w
+
+				// The background has no geometry to guide the filter
+				if depth[o] == 0 {
+					next[o], nextVariance[o] = irradiance[o], variance[o]
+					continue
+				}
+
+				lp := luminance(irradiance[o])
+				sigma := denoiseLuminance*math.Sqrt(smoothVariance[o]) + 1e-6
+
+				var sum Color
+				var weights, sumVariance float64
+
+				for ky := -2; ky <= 2; ky++ {
+					for kx := -2; kx <= 2; kx++ {
+						qx, qy := x+kx*step, y+ky*step
+						if qx < 0 || qx >= w || qy < 0 || qy >= h {
+							continue
+						}
+
+						q := qx + qy*w
+						if depth[q] == 0 {
+							continue
+						}
+
+						distance := math.Hypot(float64(kx*step), float64(ky*step))
+
+						wn := math.Pow(math.Max(0, normal[o].Dot(normal[q])), denoiseNormalPower)
+						wz := math.Exp(-math.Abs(depth[o]-depth[q]) / (denoiseDepth*depth[o]*distance + 1e-6))
+						wl := math.Exp(-math.Abs(lp-luminance(irradiance[q])) / sigma)
+
+						weight := kernel[kx+2] * kernel[ky+2] * wn * wz * wl
+
+						sum = sum.Add(irradiance[q].Mul(weight))
+						weights += weight
+						sumVariance += weight * weight * variance[q]
+					}
+				}
+
+				// The weight of the center tap underflows if its normal is short (e.g. the samples hit both sides of an edge)
+				if weights <= 0 {
+					next[o], nextVariance[o] = irradiance[o], variance[o]
+					continue
+				}
+
+				next[o] = sum.Div(weights)
+				nextVariance[o] = sumVariance / (weights * weights)
+			}
+		}
+
+		irradiance, next = next, irradiance
+		variance, nextVariance = nextVariance, variance
+	}
+
+	// Multiply by the albedo again, each pixel keeps its number of samples
+	denoised := &Film{Width: w, Height: h, Sum: make([]Color, n), Count: film.Count, Layers: film.Layers,
+		Scene: film.Scene, SampleSeeds: film.SampleSeeds, Filtered: true}
+
+	for o := range denoised.Sum {
+		a := NewColor(math.Max(albedo[o].X, 0.01), math.Max(albedo[o].Y, 0.01), math.Max(albedo[o].Z, 0.01))
+		denoised.Sum[o] = irradiance[o].MultiplyByComponent(a).Mul(float64(film.Count[o]))
+	}
+
+	return denoised
+}
+
+// Returns the average variance of the square of pixels around every pixel, skipping the background
+func blurVariance(variance, depth []float64, w, h, radius int) []float64 {
+	blurred := make([]float64, len(variance))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sum, count := 0.0, 0
+
+			for qy := y - radius; qy <= y+radius; qy++ {
+				for qx := x - radius; qx <= x+radius; qx++ {
+					if qx >= 0 && qx < w && qy >= 0 && qy < h && (depth[qx+qy*w] == 0) == (depth[x+y*w] == 0) {
+						sum += variance[qx+qy*w]
+						count++
+					}
+				}
+			}
+
+			blurred[x+y*w] = sum / float64(count)
+		}
+	}
+
+	return blurred
+}
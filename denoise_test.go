@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns a film of a gray wall with noisy samples, the left half facing the camera and the right half facing sideways
+// and twice as bright
+func newNoisyFilm(width, height, samples int) *Film {
+	film := NewFilm(width, height)
+	film.Scene, film.SampleSeeds = SceneIdentity{ImageNo: 2, Seed: 1, Width: width, Height: height}, []int64{42}
+
+	SeedRandom(1)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			normal, brightness := NewVec3(0, 0, 1), 0.25
+			if x >= width/2 {
+				normal, brightness = NewVec3(1, 0, 0), 0.5
+			}
+
+			for s := 0; s < samples; s++ {
+				c := NewColor(1, 1, 1).Mul(brightness * 2 * RandomDouble())
+				film.AddSample(x, y, c)
+				film.AddAovSample(x, y, DenoiseAovs, AovSample{Color: c, Depth: 10, Normal: normal, Albedo: NewColor(0.5, 0.5, 0.5)})
+			}
+		}
+	}
+
+	return film
+}
+
+// Returns the average and the standard deviation of the luminance of the pixels in the given columns
+func columnStats(film *Film, x0, x1 int) (float64, float64) {
+	var sum, squares float64
+	n := 0
+
+	for y := 0; y < film.Height; y++ {
+		for x := x0; x < x1; x++ {
+			l := luminance(film.Pixel(x, y))
+			sum += l
+			squares += l * l
+			n++
+		}
+	}
+
+	mean := sum / float64(n)
+
+	return mean, math.Sqrt(math.Max(0, squares/float64(n)-mean*mean))
+}
+
+func TestDenoise(t *testing.T) {
+	const width, height = 32, 16
+	film := newNoisyFilm(width, height, 4)
+	denoised := Denoise(film, 3)
+
+	// Each half keeps its brightness with less noise, the edge between them is not blurred
+	for _, half := range []struct {
+		name   string
+		x0, x1 int
+		mean   float64
+	}{{"left", 0, width/2 - 1, 0.25}, {"right", width/2 + 1, width, 0.5}} {
+		_, noisy := columnStats(film, half.x0, half.x1)
+		mean, noise := columnStats(denoised, half.x0, half.x1)
+
+		if math.Abs(mean-half.mean) > 0.05 || noise > noisy/2 {
+			t.Errorf("the %s half has brightness %.3f and noise %.3f, want %.3f and less than %.3f", half.name, mean, noise, half.mean, noisy/2)
+		}
+	}
+
+	if edge := luminance(denoised.Pixel(width/2-1, height/2)); edge > 0.4 {
+		t.Errorf("the dark side of the edge has brightness %.3f, the bright side leaked into it", edge)
+	}
+
+	// The denoised film belongs to the same scene, but it can't be merged with other renders
+	if denoised.Scene != film.Scene || len(denoised.SampleSeeds) != 1 || !denoised.Filtered {
+		t.Errorf("the denoised film has scene %+v, seeds %v and filtered %v", denoised.Scene, denoised.SampleSeeds, denoised.Filtered)
+	}
+
+	other := newNoisyFilm(width, height, 1)
+	other.SampleSeeds = []int64{43}
+
+	if err := other.Merge(denoised); err == nil {
+		t.Errorf("a denoised film was merged")
+	}
+}
+
+func TestDenoiseShortNormal(t *testing.T) {
+	// The samples of a pixel hit faces with opposite normals, whose average is nearly 0
+	film := newNoisyFilm(8, 8, 2)
+	film.Layers[AovNormal][3+3*8] = NewVec3(0, 0, 1e-5)
+
+	denoised := Denoise(film, 2)
+
+	for o, sum := range denoised.Sum {
+		if math.IsNaN(sum.X) || math.IsNaN(sum.Y) || math.IsNaN(sum.Z) {
+			t.Fatalf("pixel %d,%d of the denoised film is %v", o%8, o/8, sum)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "math"
+
+// The index of refraction of a dispersive material, as a function of the wavelength in nanometers
+type Dispersion interface {
+	IndexOfRefraction(wavelength float64) float64
+}
+
+// Cauchy's equation, n = A + B/λ² with λ in micrometers. It's a good fit for most glasses in the visible range.
+type CauchyDispersion struct {
+	A, B float64
+}
+
+func (cd CauchyDispersion) IndexOfRefraction(wavelength float64) float64 {
+	l := wavelength / 1000
+
+	return cd.A + cd.B/(l*l)
+}
+
+// Sellmeier's equation, n² = 1 + Σ Bᵢλ²/(λ² - Cᵢ) with λ in micrometers. Glass makers publish these coefficients.
+type SellmeierDispersion struct {
+	B, C [3]float64
+}
+
+func (sd SellmeierDispersion) IndexOfRefraction(wavelength float64) float64 {
+	l2 := wavelength * wavelength / 1e6
+	n2 := 1.0
+
+	for i := range sd.B {
+		n2 += sd.B[i] * l2 / (l2 - sd.C[i])
+	}
+
+	return math.Sqrt(n2)
+}
+
+// Some common materials
+var (
+	CrownGlass  = SellmeierDispersion{B: [3]float64{1.03961212, 0.231792344, 1.01046945}, C: [3]float64{0.00600069867, 0.0200179144, 103.560653}} // Schott N-BK7
+	FlintGlass  = SellmeierDispersion{B: [3]float64{1.73759695, 0.313747346, 1.89878101}, C: [3]float64{0.013188707, 0.0623068142, 155.23629}}    // Schott SF11
+	FusedSilica = SellmeierDispersion{B: [3]float64{0.6961663, 0.4079426, 0.8974794}, C: [3]float64{0.0046791483, 0.0135120631, 97.9340025}}
+	Diamond     = SellmeierDispersion{B: [3]float64{0.3306, 4.3356, 0}, C: [3]float64{0.030625, 0.011236, 0}}
+	Water       = CauchyDispersion{A: 1.324, B: 0.00306}
+)
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Distributed rendering: a coordinator splits the image into tiles and hands them to worker processes,
+// which talk to the coordinator with a simple protocol made of gob-encoded messages over their stdin and stdout.
+// Every tile is rendered with its own seed, so a tile can be rendered again by another worker if its worker dies.
+
+const TileSize = 32
+
+// Maximum number of times a tile is handed to a worker before giving up
+const maxTileAttempts = 3
+
+// A job asks a worker to render a tile of an image
+type TileJob struct {
+	Scene           SceneIdentity // Workers build the scene by themselves, so only its identity travels
+	SamplesPerPixel int
+	SampleSeed      int64
+	X0, Y0          int
+	Width, Height   int
+	attempts        int
+}
+
+type TileResult struct {
+	Tile  *Film
+	Rays  int64  // Number of rays traced to render the tile
+	Error string // Set if the worker cannot render the tile at all
+}
+
+// Builds the camera and the world of a scene, the camera must be initialized
+type SceneBuilder func(identity SceneIdentity, samplesPerPixel int) (Camera, Hittable, error)
+
+// A connection to a worker, usually the pipes of a local process but anything that speaks the protocol will do
+type WorkerConn interface {
+	io.ReadWriteCloser
+}
+
+type WorkerStarter func() (WorkerConn, error)
+
+// Serves tile jobs until the coordinator closes the connection.
+// Note that rendering uses the global random generator, so only one worker at a time can run in a process.
+func RunWorker(r io.Reader, w io.Writer, build SceneBuilder) error {
+	decoder := gob.NewDecoder(r)
+	encoder := gob.NewEncoder(w)
+
+	var cam Camera
+	var world Hittable
+	var built TileJob // Only the scene fields are used, to avoid building the same scene again
+
+	for {
+		job := TileJob{}
+
+		if err := decoder.Decode(&job); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		result := TileResult{}
+
+		if world == nil || job.Scene != built.Scene || job.SamplesPerPixel != built.SamplesPerPixel {
+			var err error
+
+			if cam, world, err = build(job.Scene, job.SamplesPerPixel); err != nil {
+				world = nil
+				result.Error = err.Error()
+			}
+
+			built = job
+		}
+
+		if world != nil {
+			SeedRandom(job.SampleSeed)
+
+			rays := raysTraced.Load()
+			result.Tile = NewFilm(job.Width, job.Height)
+			cam.RenderTile(world, result.Tile, job.X0, job.Y0, job.SamplesPerPixel)
+			result.Rays = raysTraced.Load() - rays
+		}
+
+		if err := encoder.Encode(&result); err != nil {
+			return err
+		}
+	}
+}
+
+type workerProcess struct {
+	cmd *exec.Cmd
+	io.Reader
+	io.WriteCloser
+}
+
+// Starts a worker running this same executable
+func StartWorkerProcess() (WorkerConn, error) {
+	executable, err := os.Executable()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(executable, "worker")
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return workerProcess{cmd, stdout, stdin}, nil
+}
+
+func (wp workerProcess) Close() error {
+	wp.WriteCloser.Close() // The worker exits when its input is closed...
+	wp.cmd.Process.Kill()  // ...but it may be stuck, so make sure it's gone
+	wp.cmd.Wait()
+	return nil
+}
+
+type tileEvent struct {
+	job    TileJob
+	result TileResult
+	err    error // Set if the worker died, the job must be retried
+	dead   bool  // Set if a worker could not be (re)started
+}
+
+// Runs a worker slot: starts a worker and feeds it jobs, restarting it whenever it dies
+func runWorkerSlot(start WorkerStarter, jobs <-chan TileJob, events chan<- tileEvent) {
+	var conn WorkerConn
+	var encoder *gob.Encoder
+	var decoder *gob.Decoder
+
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for job := range jobs {
+		if conn == nil {
+			var err error
+
+			if conn, err = start(); err != nil {
+				events <- tileEvent{job: job, err: err, dead: true}
+				return
+			}
+
+			encoder, decoder = gob.NewEncoder(conn), gob.NewDecoder(conn)
+		}
+
+		result := TileResult{}
+
+		err := encoder.Encode(&job)
+
+		if err == nil {
+			err = decoder.Decode(&result)
+		}
+
+		if err != nil {
+			conn.Close()
+			conn = nil
+		}
+
+		events <- tileEvent{job: job, result: result, err: err}
+	}
+}
+
+// Renders the image on the film using the given number of workers, every tile gets all its samples from a single worker.
+// If the context is cancelled the render stops and returns the context error, the film holds the tiles rendered so far.
+// If progress is not nil, it's called after every tile.
+func RenderDistributed(ctx context.Context, film *Film, identity SceneIdentity, samplesPerPixel int, sampleSeed int64, workers int, start WorkerStarter, progress ProgressFunc) error {
+	var tiles []TileJob
+
+	for y := 0; y < film.Height; y += TileSize {
+		for x := 0; x < film.Width; x += TileSize {
+			tile := TileJob{Scene: identity, SamplesPerPixel: samplesPerPixel, X0: x, Y0: y, Width: TileSize, Height: TileSize}
+			if x+tile.Width > film.Width {
+				tile.Width = film.Width - x
+			}
+			if y+tile.Height > film.Height {
+				tile.Height = film.Height - y
+			}
+			tile.SampleSeed = passSeed(sampleSeed, len(tiles))
+			tiles = append(tiles, tile)
+		}
+	}
+
+	jobs := make(chan TileJob, len(tiles)) // Big enough to never block, even when jobs are queued again
+	events := make(chan tileEvent)
+
+	for _, tile := range tiles {
+		jobs <- tile
+	}
+
+	slots := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		slots.Add(1)
+		go func() {
+			defer slots.Done()
+			runWorkerSlot(start, jobs, events)
+		}()
+	}
+
+	// When done, stop the workers: drop the jobs that are still queued and ignore what running jobs have to say
+	defer func() {
+		for len(jobs) > 0 {
+			<-jobs
+		}
+		close(jobs)
+
+		go func() {
+			slots.Wait()
+			close(events)
+		}()
+
+		for range events {
+		}
+	}()
+
+	pixels := film.Width * film.Height
+	tracker := newProgressTracker(1, pixels, int64(pixels*samplesPerPixel), progress)
+	tracker.startPass()
+
+	for done, alive := 0, workers; done < len(tiles); {
+		var event tileEvent
+
+		select {
+		case event = <-events:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if event.dead {
+			alive--
+		}
+
+		if event.err != nil {
+			event.job.attempts++
+
+			fmt.Fprintf(os.Stderr, "Worker failed on tile at %d, %d: %v\n", event.job.X0, event.job.Y0, event.err)
+
+			if event.job.attempts >= maxTileAttempts {
+				return fmt.Errorf("giving up on tile at %d, %d after %d attempts", event.job.X0, event.job.Y0, event.job.attempts)
+			}
+
+			if alive == 0 {
+				return fmt.Errorf("no workers left")
+			}
+
+			jobs <- event.job
+			continue
+		}
+
+		if event.result.Error != "" {
+			return fmt.Errorf("worker error: %s", event.result.Error)
+		}
+
+		tile := event.result.Tile
+
+		if tile == nil || tile.Width != event.job.Width || tile.Height != event.job.Height {
+			return fmt.Errorf("worker returned a wrong tile for %d, %d", event.job.X0, event.job.Y0)
+		}
+
+		if err := film.AddTile(tile, event.job.X0, event.job.Y0); err != nil {
+			return err
+		}
+		raysTraced.Add(event.result.Rays) // Workers trace the rays, but they are counted here
+
+		done++
+
+		tracker.update(tile.Width*tile.Height, int64(tile.Width*tile.Height*samplesPerPixel))
+	}
+
+	return nil
+}
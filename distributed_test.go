@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// A worker running in this process, connected by pipes instead of stdin and stdout
+type inProcessWorker struct {
+	jobs    *io.PipeWriter
+	results *io.PipeReader
+	done    chan struct{}
+}
+
+func startInProcessWorker() *inProcessWorker {
+	jobsR, jobsW := io.Pipe()
+	resultsR, resultsW := io.Pipe()
+
+	worker := &inProcessWorker{jobs: jobsW, results: resultsR, done: make(chan struct{})}
+
+	go func() {
+		defer close(worker.done)
+		resultsW.CloseWithError(RunWorker(jobsR, resultsW, buildScene))
+	}()
+
+	return worker
+}
+
+func (worker *inProcessWorker) Read(p []byte) (int, error) {
+	return worker.results.Read(p)
+}
+
+func (worker *inProcessWorker) Write(p []byte) (int, error) {
+	return worker.jobs.Write(p)
+}
+
+// Closes the pipes and waits for the worker to exit: it shares the random generator, so only one worker can run at a time
+func (worker *inProcessWorker) Close() error {
+	worker.jobs.Close()
+	worker.results.Close()
+	<-worker.done
+	return nil
+}
+
+// A worker that dies while rendering its first tile: the coordinator gets no result
+type dyingWorker struct {
+	*inProcessWorker
+}
+
+func (worker dyingWorker) Read(p []byte) (int, error) {
+	worker.inProcessWorker.Close()
+	return 0, errors.New("worker killed")
+}
+
+func TestRenderDistributedRetriesTiles(t *testing.T) {
+	identity := SceneIdentity{ImageNo: 2, Seed: 1}
+	const samplesPerPixel, sampleSeed = 2, 42
+
+	cam, world, err := buildScene(identity, samplesPerPixel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity.Width, identity.Height = cam.imageWidth, cam.imageHeight
+
+	starts := 0
+	start := func() (WorkerConn, error) {
+		starts++
+		if starts == 1 {
+			return dyingWorker{startInProcessWorker()}, nil
+		}
+		return startInProcessWorker(), nil
+	}
+
+	film := NewFilm(cam.imageWidth, cam.imageHeight)
+
+	if err := RenderDistributed(context.Background(), film, identity, samplesPerPixel, sampleSeed, 1, start, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if starts != 2 {
+		t.Errorf("the worker was started %d times, want 2", starts)
+	}
+
+	// The same tiles rendered in this process, with the same seeds
+	want := NewFilm(cam.imageWidth, cam.imageHeight)
+	tiles := 0
+
+	for y := 0; y < want.Height; y += TileSize {
+		for x := 0; x < want.Width; x += TileSize {
+			tile := NewFilm(TileSize, TileSize)
+			if x+TileSize > want.Width {
+				tile = NewFilm(want.Width-x, tile.Height)
+			}
+			if y+TileSize > want.Height {
+				tile = NewFilm(tile.Width, want.Height-y)
+			}
+
+			SeedRandom(passSeed(sampleSeed, tiles))
+			cam.RenderTile(world, tile, x, y, samplesPerPixel)
+
+			if err := want.AddTile(tile, x, y); err != nil {
+				t.Fatal(err)
+			}
+
+			tiles++
+		}
+	}
+
+	for i := range want.Sum {
+		if film.Count[i] != samplesPerPixel || film.Sum[i] != want.Sum[i] {
+			t.Fatalf("pixel %d is %v with %d samples, want %v with %d samples", i, film.Sum[i], film.Count[i], want.Sum[i], samplesPerPixel)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// A film accumulates the color samples of every pixel as floating point sums, so that more samples can be added at any time
+// (e.g. by further rendering passes) without losing precision. The final color of a pixel is the average of its samples.
+type Film struct {
+	Width  int
+	Height int
+	Sum    []Color            // Sum of all the samples of each pixel
+	Count  []int              // Number of samples of each pixel
+	Layers map[string][]Color // Auxiliary outputs (AOVs), sums of the values of all the samples like Sum (IDs are not summed, see isIDLayer)
+	Groups [][]Color          // Robust mean: the samples are also summed in groups, the pixel color is the median of the group averages
+
+	Scene       SceneIdentity // Scene the samples belong to, the zero value if it's unknown (e.g. the film was read from an image)
+	SampleSeeds []int64       // Sample seeds of the renders whose samples the film holds, renders with the same seed have the same samples
+	Filtered    bool          // The pixels were changed by a filter (e.g. denoised), so they are no longer sums of samples
+}
+
+func NewFilm(width, height int) *Film {
+	return &Film{Width: width, Height: height, Sum: make([]Color, width*height), Count: make([]int, width*height)}
+}
+
+func (film *Film) AddSample(x, y int, c Color) {
+	o := x + y*film.Width // Offset into our data arrays
+
+	film.Sum[o] = film.Sum[o].Add(c)
+	film.Count[o]++
+
+	if len(film.Groups) > 0 {
+		g := (film.Count[o] - 1) % len(film.Groups)
+		film.Groups[g][o] = film.Groups[g][o].Add(c)
+	}
+}
+
+// Adds the layers with the given names, if they are missing
+func (film *Film) AddLayers(names []string) {
+	for _, name := range names {
+		if _, ok := film.Layers[name]; ok {
+			continue
+		}
+
+		if film.Layers == nil {
+			film.Layers = map[string][]Color{}
+		}
+
+		film.Layers[name] = make([]Color, film.Width*film.Height)
+	}
+}
+
+// Adds the auxiliary outputs of a sample to the layers with the given names, it must follow the AddSample of the same sample
+func (film *Film) AddAovSample(x, y int, names []string, aov AovSample) {
+	film.AddLayers(names)
+
+	o := x + y*film.Width
+
+	for _, name := range names {
+		film.addLayerSample(name, o, aov.Value(name), film.Count[o]-1)
+	}
+}
+
+// Adds a value to a layer pixel that already has the given number of samples
+func (film *Film) addLayerSample(name string, o int, value Color, count int) {
+	layer := film.Layers[name]
+
+	if !isIDLayer(name) {
+		layer[o] = layer[o].Add(value)
+	} else if count == 0 {
+		layer[o] = value
+	}
+}
+
+// Returns the value of a pixel of a layer, the average of its samples or the ID of the first one
+func (film *Film) LayerPixel(name string, x, y int) Color {
+	o := x + y*film.Width
+
+	if film.Count[o] == 0 || isIDLayer(name) {
+		return film.Layers[name][o]
+	}
+
+	value := film.Layers[name][o].Div(float64(film.Count[o]))
+
+	if name == AovVariance {
+		// The average of the squares minus the square of the average
+		mean := film.Pixel(x, y)
+		value = value.Sub(mean.MultiplyByComponent(mean))
+		value = NewColor(math.Max(0, value.X), math.Max(0, value.Y), math.Max(0, value.Z))
+	}
+
+	return value
+}
+
+// Returns a film made of the given rows of this film, which shares their data
+func (film *Film) Rows(y, rows int) *Film {
+	begin, end := y*film.Width, (y+rows)*film.Width
+
+	part := &Film{Width: film.Width, Height: rows, Sum: film.Sum[begin:end], Count: film.Count[begin:end]}
+
+	for _, group := range film.Groups {
+		part.Groups = append(part.Groups, group[begin:end])
+	}
+
+	for name, layer := range film.Layers {
+		if part.Layers == nil {
+			part.Layers = map[string][]Color{}
+		}
+
+		part.Layers[name] = layer[begin:end]
+	}
+
+	return part
+}
+
+// Returns an error if the two films don't have the same layers: the layers are sums over the samples of the color, so
+// adding the samples of one film to the other would leave some layers with fewer samples than the color
+func (film *Film) checkLayers(other *Film) error {
+	for name := range film.Layers {
+		if _, ok := other.Layers[name]; !ok {
+			return fmt.Errorf("cannot add a film without the %s layer", name)
+		}
+	}
+
+	for name := range other.Layers {
+		if _, ok := film.Layers[name]; !ok {
+			return fmt.Errorf("cannot add a film with the %s layer to a film without it", name)
+		}
+	}
+
+	return nil
+}
+
+// Adds the samples of a film that covers a tile of this film, with the top left corner at x0, y0.
+// The films must have the same layers.
+func (film *Film) AddTile(tile *Film, x0, y0 int) error {
+	if err := film.checkLayers(tile); err != nil {
+		return err
+	}
+
+	for y := 0; y < tile.Height; y++ {
+		for x := 0; x < tile.Width; x++ {
+			o, to := x0+x+(y0+y)*film.Width, x+y*tile.Width
+
+			for name, layer := range tile.Layers {
+				film.addLayerSample(name, o, layer[to], film.Count[o])
+			}
+
+			film.addGroups(tile, o, to)
+
+			film.Sum[o] = film.Sum[o].Add(tile.Sum[to])
+			film.Count[o] += tile.Count[to]
+		}
+	}
+
+	return nil
+}
+
+// Replaces the pixels of a tile of this film with the pixels of a film that covers it, with the top left corner at x0, y0.
+// The films must have the same layers and robust mean groups, otherwise some of them would be left with the old samples.
+func (film *Film) CopyTile(tile *Film, x0, y0 int) error {
+	if len(film.Groups) != len(tile.Groups) {
+		return fmt.Errorf("cannot copy a tile with %d robust mean groups into a film with %d", len(tile.Groups), len(film.Groups))
+	}
+
+	if err := film.checkLayers(tile); err != nil {
+		return err
+	}
+
+	for y := 0; y < tile.Height; y++ {
+		o, to := x0+(y0+y)*film.Width, y*tile.Width
+
+		copy(film.Sum[o:o+tile.Width], tile.Sum[to:to+tile.Width])
+		copy(film.Count[o:o+tile.Width], tile.Count[to:to+tile.Width])
+
+		for name, layer := range tile.Layers {
+			copy(film.Layers[name][o:o+tile.Width], layer[to:to+tile.Width])
+		}
+
+		for g, group := range tile.Groups {
+			copy(film.Groups[g][o:o+tile.Width], group[to:to+tile.Width])
+		}
+	}
+
+	return nil
+}
+
+// Returns the average color of a pixel, in linear space
+func (film *Film) Pixel(x, y int) Color {
+	o := x + y*film.Width
+
+	if film.Count[o] == 0 {
+		return Color{}
+	}
+
+	if len(film.Groups) > 0 {
+		return film.robustPixel(o)
+	}
+
+	return film.Sum[o].Div(float64(film.Count[o]))
+}
+
+// Returns the smallest number of samples taken by a pixel
+func (film *Film) Samples() int {
+	samples := 0
+
+	for i, count := range film.Count {
+		if i == 0 || count < samples {
+			samples = count
+		}
+	}
+
+	return samples
+}
+
+func (film *Film) WritePPM(w io.Writer) {
+	fmt.Fprintf(w, "P3\n") // Magic
+	fmt.Fprintf(w, "# Samples per pixel: %d\n", film.Samples())
+	fmt.Fprintf(w, "%d %d\n", film.Width, film.Height)
+	fmt.Fprintf(w, "255\n") // Maximum value of a color component
+
+	for y := 0; y < film.Height; y++ {
+		for x := 0; x < film.Width; x++ {
+			c := film.Pixel(x, y)
+			// Note: because of the lights, it's possible that some color components are still greater than 1,
+			// this will be taken care of in the LinearToRGB() function
+
+			// Apply gamma correction and convert to the standard RGB range
+			ir := LinearToRGB(c.X)
+			ig := LinearToRGB(c.Y)
+			ib := LinearToRGB(c.Z)
+
+			fmt.Fprintf(w, "%d %d %d\n", ir, ig, ib)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w)
+}
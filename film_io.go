@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Supported output formats, chosen by the file extension:
+// .ppm  - 8-bit gamma corrected plain PPM (the default)
+// .png  - 8-bit gamma corrected PNG
+// .pfm  - 32-bit linear Portable Float Map
+// .film - the film itself, with sample sums and counts, it can be merged with other renders of the same scene
+func WriteFilm(filename string, film *Film) error {
+	f, err := os.Create(filename)
+
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".png":
+		err = film.WritePNG(w)
+	case ".pfm":
+		err = film.WritePFM(w)
+	case ".film":
+		err = gob.NewEncoder(w).Encode(film)
+	default:
+		film.WritePPM(w)
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Writes the given layers of the film, each to its own PFM file, named after the output file and the layer (e.g. out_depth.pfm).
+// The .film format holds the layers already, so nothing is written.
+func WriteLayers(filename string, film *Film, names []string) error {
+	ext := filepath.Ext(filename)
+
+	if strings.ToLower(ext) == ".film" {
+		return nil
+	}
+
+	for _, name := range names {
+		if err := WriteFilm(strings.TrimSuffix(filename, ext)+"_"+name+".pfm", film.LayerFilm(name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns a film with one sample per pixel, whose colors are the values of a layer
+func (film *Film) LayerFilm(name string) *Film {
+	colors := make([]Color, film.Width*film.Height)
+
+	for y := 0; y < film.Height; y++ {
+		for x := 0; x < film.Width; x++ {
+			colors[x+y*film.Width] = film.LayerPixel(name, x, y)
+		}
+	}
+
+	return newFilmFromColors(film.Width, film.Height, colors)
+}
+
+// Returns a film with one sample per pixel, whose colors are the colors of this film (e.g. the robust mean of the pixels)
+func (film *Film) ColorFilm() *Film {
+	colors := make([]Color, film.Width*film.Height)
+
+	for y := 0; y < film.Height; y++ {
+		for x := 0; x < film.Width; x++ {
+			colors[x+y*film.Width] = film.Pixel(x, y)
+		}
+	}
+
+	return newFilmFromColors(film.Width, film.Height, colors)
+}
+
+// Reads a film from a .film file or from a progressive render checkpoint
+func ReadFilm(filename string) (*Film, error) {
+	film, err := readFilm(filename)
+
+	if err == nil && (len(film.Sum) != film.Width*film.Height || len(film.Count) != len(film.Sum)) {
+		err = fmt.Errorf("film %s is corrupted", filename)
+	}
+
+	if err == nil {
+		for _, layer := range film.Layers {
+			if len(layer) != len(film.Sum) {
+				err = fmt.Errorf("film %s is corrupted", filename)
+			}
+		}
+
+		for _, group := range film.Groups {
+			if len(group) != len(film.Sum) {
+				err = fmt.Errorf("film %s is corrupted", filename)
+			}
+		}
+	}
+
+	return film, err
+}
+
+func readFilm(filename string) (*Film, error) {
+	if strings.ToLower(filepath.Ext(filename)) != ".film" {
+		checkpoint, err := LoadCheckpoint(filename)
+
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoint.Film.Scene, checkpoint.Film.SampleSeeds = checkpoint.Scene, []int64{checkpoint.SampleSeed}
+
+		return checkpoint.Film, nil
+	}
+
+	f, err := os.Open(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	film := &Film{}
+
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(film); err != nil {
+		return nil, fmt.Errorf("cannot decode film %s: %w", filename, err)
+	}
+
+	return film, nil
+}
+
+// Reads an image in any of the output formats. The 8-bit formats only keep the final colors, so every pixel is read
+// as a single sample (and the colors brighter than white are lost).
+func ReadImage(filename string) (*Film, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	if ext == ".film" {
+		return ReadFilm(filename)
+	}
+
+	f, err := os.Open(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var film *Film
+
+	switch ext {
+	case ".png":
+		film, err = readPNG(bufio.NewReader(f))
+	case ".pfm":
+		film, err = readPFM(bufio.NewReader(f))
+	default:
+		film, err = readPPM(bufio.NewReader(f))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image %s: %w", filename, err)
+	}
+
+	return film, nil
+}
+
+// Returns a film with one sample per pixel, of the given colors
+func newFilmFromColors(width, height int, colors []Color) *Film {
+	film := NewFilm(width, height)
+
+	copy(film.Sum, colors)
+	for i := range film.Count {
+		film.Count[i] = 1
+	}
+
+	return film
+}
+
+func readPNG(r io.Reader) (*Film, error) {
+	img, err := png.Decode(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	colors := make([]Color, w*h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			colors[x+y*w] = NewColor(GammaToLinear(float64(r)/0xffff), GammaToLinear(float64(g)/0xffff), GammaToLinear(float64(b)/0xffff))
+		}
+	}
+
+	return newFilmFromColors(w, h, colors), nil
+}
+
+// Reads a plain PPM file, like the ones written by WritePPM
+func readPPM(r io.Reader) (*Film, error) {
+	var values []int
+
+	// Collect all the numbers after the magic, skipping the comments
+	scanner := bufio.NewScanner(r)
+	magic := false
+
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		for _, field := range strings.Fields(line) {
+			if !magic {
+				if field != "P3" {
+					return nil, fmt.Errorf("not a plain PPM file")
+				}
+				magic = true
+				continue
+			}
+
+			value, err := strconv.Atoi(field)
+
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(values) < 3 {
+		return nil, fmt.Errorf("truncated PPM header")
+	}
+
+	w, h, maxValue := values[0], values[1], float64(values[2])
+	values = values[3:]
+
+	if len(values) != w*h*3 {
+		return nil, fmt.Errorf("the PPM file has %d values instead of %d", len(values), w*h*3)
+	}
+
+	colors := make([]Color, w*h)
+
+	for i := range colors {
+		colors[i] = NewColor(GammaToLinear(float64(values[i*3])/maxValue), GammaToLinear(float64(values[i*3+1])/maxValue), GammaToLinear(float64(values[i*3+2])/maxValue))
+	}
+
+	return newFilmFromColors(w, h, colors), nil
+}
+
+// Reads a color PFM file, like the ones written by WritePFM
+func readPFM(r *bufio.Reader) (*Film, error) {
+	var w, h int
+	var scale float64
+
+	if _, err := fmt.Fscanf(r, "PF\n%d %d\n%g\n", &w, &h, &scale); err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		order = binary.LittleEndian
+	}
+
+	colors := make([]Color, w*h)
+	row := make([]float32, w*3)
+
+	for y := h - 1; y >= 0; y-- {
+		if err := binary.Read(r, order, row); err != nil {
+			return nil, err
+		}
+
+		for x := 0; x < w; x++ {
+			colors[x+y*w] = NewColor(float64(row[x*3]), float64(row[x*3+1]), float64(row[x*3+2]))
+		}
+	}
+
+	return newFilmFromColors(w, h, colors), nil
+}
+
+func (film *Film) WritePNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, film.Width, film.Height))
+
+	for y := 0; y < film.Height; y++ {
+		for x := 0; x < film.Width; x++ {
+			c := film.Pixel(x, y)
+			img.SetRGBA(x, y, color.RGBA{uint8(LinearToRGB(c.X)), uint8(LinearToRGB(c.Y)), uint8(LinearToRGB(c.Z)), 255})
+		}
+	}
+
+	buf := bytes.Buffer{}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	// Record the number of samples in a text chunk, placed right after the header chunk (which follows the 8 bytes signature)
+	const headerEnd = 8 + 4 + 4 + 13 + 4 // Length, type, data and CRC of the header chunk
+
+	data := buf.Bytes()
+	text := []byte(fmt.Sprintf("tEXtComment\x00Samples per pixel: %d", film.Samples()))
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(text))
+
+	for _, part := range [][]byte{data[:headerEnd], chunk, data[headerEnd:]} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The PFM format stores linear colors, with scanlines from bottom to top
+func (film *Film) WritePFM(w io.Writer) error {
+	fmt.Fprintf(w, "PF\n%d %d\n-1.0\n", film.Width, film.Height) // A negative scale means little endian
+
+	row := make([]float32, film.Width*3)
+
+	for y := film.Height - 1; y >= 0; y-- {
+		for x := 0; x < film.Width; x++ {
+			c := film.Pixel(x, y)
+			row[x*3], row[x*3+1], row[x*3+2] = float32(c.X), float32(c.Y), float32(c.Z)
+		}
+
+		if err := binary.Write(w, binary.LittleEndian, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Adds the samples of another render of the same scene, so each pixel becomes the average of all the samples of both films.
+// The renders must have different sample seeds, otherwise their samples are the same and merging them gains nothing,
+// and the same layers. Filtered films can't be merged, as more samples wouldn't make the filtered pixels any better.
+func (film *Film) Merge(other *Film) error {
+	if film.Width != other.Width || film.Height != other.Height {
+		return fmt.Errorf("cannot merge a %dx%d film into a %dx%d one", other.Width, other.Height, film.Width, film.Height)
+	}
+
+	if film.Filtered || other.Filtered {
+		return fmt.Errorf("cannot merge a filtered film (-denoise or -fireflies), merge the renders before filtering them")
+	}
+
+	if film.Scene != (SceneIdentity{}) && other.Scene != (SceneIdentity{}) && film.Scene != other.Scene {
+		return fmt.Errorf("cannot merge renders of different scenes, %+v and %+v", other.Scene, film.Scene)
+	}
+
+	for _, seed := range other.SampleSeeds {
+		if slices.Contains(film.SampleSeeds, seed) {
+			return fmt.Errorf("cannot merge two renders with the same sample seed %d, their samples are the same", seed)
+		}
+	}
+
+	if len(film.Groups) != len(other.Groups) {
+		return fmt.Errorf("cannot merge a film with %d robust mean groups into one with %d", len(other.Groups), len(film.Groups))
+	}
+
+	if err := film.checkLayers(other); err != nil {
+		return err
+	}
+
+	for i := range film.Sum {
+		for name, layer := range other.Layers {
+			film.addLayerSample(name, i, layer[i], film.Count[i])
+		}
+
+		film.addGroups(other, i, i)
+
+		film.Sum[i] = film.Sum[i].Add(other.Sum[i])
+		film.Count[i] += other.Count[i]
+	}
+
+	film.SampleSeeds = append(film.SampleSeeds, other.SampleSeeds...)
+
+	if film.Scene == (SceneIdentity{}) {
+		film.Scene = other.Scene
+	}
+
+	return nil
+}
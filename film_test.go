@@ -0,0 +1,84 @@
+package main
+
+import "testing"
+
+func TestMergeChecks(t *testing.T) {
+	newFilm := func(scene SceneIdentity, seed int64, layers ...string) *Film {
+		film := NewFilm(2, 2)
+		film.Scene, film.SampleSeeds = scene, []int64{seed}
+		film.AddLayers(layers)
+		return film
+	}
+
+	scene := SceneIdentity{ImageNo: 2, Seed: 1, Width: 2, Height: 2}
+	other := SceneIdentity{ImageNo: 2, Seed: 2, Width: 2, Height: 2}
+
+	tests := []struct {
+		name   string
+		a, b   *Film
+		merged bool
+	}{
+		{"same scene", newFilm(scene, 1), newFilm(scene, 2), true},
+		{"unknown scene", newFilm(scene, 1), newFilm(SceneIdentity{}, 2), true},
+		{"same seed", newFilm(scene, 1), newFilm(scene, 1), false},
+		{"different scenes", newFilm(scene, 1), newFilm(other, 2), false},
+		{"same layers", newFilm(scene, 1, AovDepth), newFilm(scene, 2, AovDepth), true},
+		{"missing layer", newFilm(scene, 1, AovDepth), newFilm(scene, 2), false},
+		{"extra layer", newFilm(scene, 1), newFilm(scene, 2, AovNormal), false},
+	}
+
+	for _, test := range tests {
+		if err := test.a.Merge(test.b); (err == nil) != test.merged {
+			t.Errorf("%s: merge returned %v, want merged %v", test.name, err, test.merged)
+		}
+	}
+
+	if err := newFilm(scene, 1, AovDepth).AddTile(NewFilm(1, 1), 0, 0); err == nil {
+		t.Errorf("a tile without the depth layer was added")
+	}
+}
+
+func TestCopyTile(t *testing.T) {
+	newFilm := func(width, height, groups int, layers ...string) *Film {
+		film := NewFilm(width, height)
+		if groups > 0 {
+			film.SetRobustGroups(groups)
+		}
+		film.AddLayers(layers)
+		return film
+	}
+
+	// The copied pixels take the samples of the tile, in the color, the groups and the layers
+	film, tile := newFilm(4, 4, 3, AovDepth), newFilm(2, 1, 3, AovDepth)
+	tile.AddSample(1, 0, NewColor(1, 2, 3))
+	tile.AddAovSample(1, 0, []string{AovDepth}, AovSample{Depth: 5})
+	film.AddSample(2, 1, NewColor(9, 9, 9))
+	film.AddAovSample(2, 1, []string{AovDepth}, AovSample{Depth: 7})
+
+	if err := film.CopyTile(tile, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if film.Count[2+film.Width] != 1 || film.Pixel(2, 1) != NewColor(1, 2, 3) || film.LayerPixel(AovDepth, 2, 1).X != 5 {
+		t.Errorf("the copied pixel has %d samples, color %v and depth %v", film.Count[2+film.Width], film.Pixel(2, 1), film.LayerPixel(AovDepth, 2, 1))
+	}
+
+	if film.Count[1+film.Width] != 0 || film.Groups[0][1+film.Width] != (Color{}) {
+		t.Errorf("the empty pixel of the tile wasn't copied")
+	}
+
+	// Groups and layers that the tile doesn't have would keep the old samples
+	for _, test := range []struct {
+		name       string
+		film, tile *Film
+	}{
+		{"without robust groups", newFilm(4, 4, 3), newFilm(2, 2, 0)},
+		{"with other robust groups", newFilm(4, 4, 3), newFilm(2, 2, 5)},
+		{"without the depth layer", newFilm(4, 4, 0, AovDepth), newFilm(2, 2, 0)},
+		{"with an extra layer", newFilm(4, 4, 0), newFilm(2, 2, 0, AovNormal)},
+	} {
+		if err := test.film.CopyTile(test.tile, 0, 0); err == nil {
+			t.Errorf("a tile %s was copied", test.name)
+		}
+	}
+}
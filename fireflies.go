@@ -0,0 +1,129 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Fireflies are isolated bright pixels, made by rare paths that carry a lot of light (e.g. caustics through glass onto a light).
+// They take a huge number of samples to average out, these are three ways to get rid of them.
+
+// Scales down a sample whose brightest component exceeds the clamp, keeping its hue. Clamping loses energy,
+// so the image gets darker where the bright paths are important.
+func clampSample(c Color, clamp float64) Color {
+	brightest := math.Max(c.X, math.Max(c.Y, c.Z))
+
+	if clamp <= 0 || brightest <= clamp {
+		return c
+	}
+
+	return c.Mul(clamp / brightest)
+}
+
+// Enables the robust mean: the samples of every pixel are also summed in the given number of groups (in turn),
+// and the color of the pixel is the median of the averages of the groups ("median of means"). A rare bright sample
+// only spoils one group, so it's ignored, at the cost of a darker image (much darker with few samples per group, when most
+// groups miss the rare paths that carry most of the light). It must be enabled before adding samples.
+func (film *Film) SetRobustGroups(groups int) {
+	film.Groups = make([][]Color, groups)
+
+	for g := range film.Groups {
+		film.Groups[g] = make([]Color, film.Width*film.Height)
+	}
+}
+
+// Adds the groups of the pixel to of another film to the groups of the pixel o, the samples of the other film follow
+// those already taken, so its groups are rotated
+func (film *Film) addGroups(other *Film, o, to int) {
+	groups := len(film.Groups)
+
+	if groups == 0 || len(other.Groups) != groups {
+		return
+	}
+
+	for g := range other.Groups {
+		h := (film.Count[o] + g) % groups
+		film.Groups[h][o] = film.Groups[h][o].Add(other.Groups[g][to])
+	}
+}
+
+// Returns the median of the group averages of a pixel, ordered by luminance
+func (film *Film) robustPixel(o int) Color {
+	groups := len(film.Groups)
+	count := film.Count[o]
+
+	var means []Color
+
+	for g := 0; g < groups; g++ {
+		n := count / groups
+		if g < count%groups {
+			n++
+		}
+
+		if n > 0 {
+			means = append(means, film.Groups[g][o].Div(float64(n)))
+		}
+	}
+
+	sort.Slice(means, func(i, j int) bool { return luminance(means[i]) < luminance(means[j]) })
+
+	mid := len(means) / 2
+
+	if len(means)%2 == 1 {
+		return means[mid]
+	}
+
+	return means[mid-1].Add(means[mid]).Mul(0.5)
+}
+
+// Replaces the pixels that are much brighter than all their neighbors with the median of the neighbors.
+// A pixel is a firefly if its luminance exceeds the brightest neighbor by more than threshold times the standard error
+// of the neighbors (how much their averages can be off, given the variance and the number of their samples).
+// The film must have the AovVariance layer.
+func FilterFireflies(film *Film, threshold float64) *Film {
+	w, h := film.Width, film.Height
+
+	filtered := &Film{Width: w, Height: h, Sum: make([]Color, w*h), Count: film.Count, Layers: film.Layers, Groups: film.Groups,
+		Scene: film.Scene, SampleSeeds: film.SampleSeeds, Filtered: true}
+	copy(filtered.Sum, film.Sum)
+
+	if len(film.Groups) > 0 {
+		// The pixel colors don't come from the sums, replace them with plain averages
+		filtered.Groups = nil
+		for o := range filtered.Sum {
+			filtered.Sum[o] = film.Pixel(o%w, o/w).Mul(float64(film.Count[o]))
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var neighbors []Color
+			brightest, standardError := 0.0, 0.0
+
+			for qy := y - 1; qy <= y+1; qy++ {
+				for qx := x - 1; qx <= x+1; qx++ {
+					if (qx == x && qy == y) || qx < 0 || qx >= w || qy < 0 || qy >= h || film.Count[qx+qy*w] == 0 {
+						continue
+					}
+
+					c := film.Pixel(qx, qy)
+					neighbors = append(neighbors, c)
+					brightest = math.Max(brightest, luminance(c))
+					standardError = math.Max(standardError, math.Sqrt(luminance(film.LayerPixel(AovVariance, qx, qy))/float64(film.Count[qx+qy*w])))
+				}
+			}
+
+			o := x + y*w
+
+			if len(neighbors) == 0 || film.Count[o] == 0 || luminance(film.Pixel(x, y)) <= brightest+threshold*standardError {
+				continue
+			}
+
+			sort.Slice(neighbors, func(i, j int) bool { return luminance(neighbors[i]) < luminance(neighbors[j]) })
+
+			filtered.Sum[o] = neighbors[len(neighbors)/2].Mul(float64(film.Count[o]))
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,133 @@
+package main
+
+import "testing"
+
+func TestClampSample(t *testing.T) {
+	for _, test := range []struct {
+		c     Color
+		clamp float64
+		want  Color
+	}{
+		{NewColor(0.5, 1, 2), 0, NewColor(0.5, 1, 2)},
+		{NewColor(0.5, 1, 2), 4, NewColor(0.5, 1, 2)},
+		{NewColor(0.5, 1, 2), 2, NewColor(0.5, 1, 2)},
+		{NewColor(0.5, 1, 2), 1, NewColor(0.25, 0.5, 1)},
+		{NewColor(8, 2, 0), 4, NewColor(4, 1, 0)},
+	} {
+		if got := clampSample(test.c, test.clamp); !nearVec3(got, test.want, 1e-12) {
+			t.Errorf("clamping %v to %v gives %v, want %v", test.c, test.clamp, got, test.want)
+		}
+	}
+}
+
+func TestRobustPixel(t *testing.T) {
+	for _, test := range []struct {
+		groups  int
+		samples []float64
+		want    float64
+	}{
+		{3, []float64{1, 1, 1, 1, 100, 1}, 1},   // The groups average 1, 50.5 and 1
+		{3, []float64{1, 2, 3, 7}, 3},           // The groups average 4, 2 and 3: the median is the middle one
+		{2, []float64{1, 3, 3, 5}, 3},           // The groups average 2 and 4: the median is their mean
+		{4, []float64{100, 2}, 51},              // Groups without samples don't count
+		{3, []float64{4, 100, 6, 8, 100, 6}, 6}, // The groups average 6, 100 and 6
+	} {
+		film := NewFilm(1, 1)
+		film.SetRobustGroups(test.groups)
+
+		for _, s := range test.samples {
+			film.AddSample(0, 0, NewColor(s, s, s))
+		}
+
+		if got := film.Pixel(0, 0); !nearVec3(got, NewColor(test.want, test.want, test.want), 1e-12) {
+			t.Errorf("the robust mean of %v in %d groups is %v, want %v", test.samples, test.groups, got.X, test.want)
+		}
+	}
+}
+
+func TestAddGroups(t *testing.T) {
+	// Merging the samples of two films fills the groups as if one film took all the samples in turn
+	samples := []float64{1, 2, 3, 4, 5, 6, 7}
+	const split = 2
+
+	newFilm := func(samples []float64) *Film {
+		film := NewFilm(1, 1)
+		film.SetRobustGroups(3)
+
+		for _, s := range samples {
+			film.AddSample(0, 0, NewColor(s, s, s))
+		}
+
+		return film
+	}
+
+	want, merged, tiled := newFilm(samples), newFilm(samples[:split]), newFilm(samples[:split])
+
+	if err := merged.Merge(newFilm(samples[split:])); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tiled.AddTile(newFilm(samples[split:]), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for g := range want.Groups {
+		if merged.Groups[g][0] != want.Groups[g][0] || tiled.Groups[g][0] != want.Groups[g][0] {
+			t.Errorf("group %d holds %v merged and %v added as a tile, want %v", g, merged.Groups[g][0], tiled.Groups[g][0], want.Groups[g][0])
+		}
+	}
+}
+
+func TestFilterFireflies(t *testing.T) {
+	const size, samples = 5, 4
+
+	film := NewFilm(size, size)
+	film.Scene, film.SampleSeeds = SceneIdentity{ImageNo: 2, Seed: 1, Width: size, Height: size}, []int64{42}
+
+	brightness := func(x, y int) float64 {
+		switch {
+		case x == 2 && y == 2:
+			return 50 // A firefly
+		case x == 0 && y == 0:
+			return 1 // Bright, but within the noise of its neighbors
+		}
+		return 0.5
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			for s := 0; s < samples; s++ {
+				c := NewColor(1, 1, 1).Mul(brightness(x, y) * float64(s+1) / 2.5)
+				film.AddSample(x, y, c)
+				film.AddAovSample(x, y, []string{AovVariance}, AovSample{Color: c})
+			}
+		}
+	}
+
+	filtered := FilterFireflies(film, 5)
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			want := brightness(x, y)
+			if x == 2 && y == 2 {
+				want = 0.5
+			}
+
+			if got := filtered.Pixel(x, y); !nearVec3(got, NewColor(want, want, want), 1e-9) {
+				t.Errorf("pixel %d,%d is %v after filtering, want %v", x, y, got.X, want)
+			}
+		}
+	}
+
+	// The filtered film belongs to the same scene, but it can't be merged with other renders
+	if filtered.Scene != film.Scene || len(filtered.SampleSeeds) != 1 || !filtered.Filtered {
+		t.Errorf("the filtered film has scene %+v, seeds %v and filtered %v", filtered.Scene, filtered.SampleSeeds, filtered.Filtered)
+	}
+
+	other := NewFilm(size, size)
+	other.AddLayers([]string{AovVariance})
+
+	if err := other.Merge(filtered); err == nil {
+		t.Errorf("a filtered film was merged")
+	}
+}
@@ -7,6 +7,8 @@ type HitRecord struct {
 	FrontFace bool     // Whether the ray hit the surface from outside (true) or inside (false)
 	Mat       Material // Surface material
 	U, V      float64  // Coordinates of hit point relative to surface
+	Tangent   Vec3     // Direction in which U grows on the surface (dp/du), not normalized, zero if the surface has no such direction
+	ObjectID  int      // Number of the object in its scene list, starting from 1
 }
 
 type Hittable interface {
@@ -15,6 +17,30 @@ type Hittable interface {
 	BoundingBox() Aabb
 }
 
+// Returns the objects contained in a Hittable, for the types that contain other objects
+func hittableChildren(object Hittable) []Hittable {
+	switch h := object.(type) {
+	case HittableList:
+		return h.objects
+	case BvhNode:
+		return []Hittable{h.left, h.right}
+	case Translate:
+		return []Hittable{h.object}
+	case RotateY:
+		return []Hittable{h.object}
+	case Transform:
+		return []Hittable{h.object}
+	case AnimatedTransform:
+		return []Hittable{h.object}
+	case KeyframedTransform:
+		return []Hittable{h.object}
+	case ConstantMedium:
+		return []Hittable{h.boundary}
+	}
+
+	return nil
+}
+
 // A normal to an object surface may point outwards or inwards... how do we choose?
 // There are two main conventions:
 // 1. the normal always points outwards
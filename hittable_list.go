@@ -22,11 +22,12 @@ func (hl HittableList) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bo
 	tempRec := HitRecord{}
 	hitAnything := false
 	closestSoFar := rayTmax
-	for _, object := range hl.objects {
+	for i, object := range hl.objects {
 		if object.Hit(ray, rayTmin, closestSoFar, &tempRec) {
 			hitAnything = true
 			closestSoFar = tempRec.T
 			*rec = tempRec
+			rec.ObjectID = i + 1 // Objects of a list inside a list get the number of the outer list
 		}
 	}
 
@@ -1,9 +1,5 @@
 package main
 
-import (
-	"io"
-)
-
 func addRandomSpheresToWorld(world *HittableList) {
 	ref := NewPoint3(4, 0.2, 0)
 	for a := -11; a < 11; a++ {
@@ -34,7 +30,7 @@ func addRandomSpheresToWorld(world *HittableList) {
 	}
 }
 
-func Image1(w io.Writer) {
+func Image1() (Camera, Hittable) {
 	world := NewHittableList()
 
 	materialGround := NewLambertianMaterial(NewColor(0.5, 0.5, 0.5))
@@ -58,5 +54,5 @@ func Image1(w io.Writer) {
 
 	world_bvh := NewBhvTree(world)
 
-	cam.Render(w, world_bvh)
+	return cam, world_bvh
 }
@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image10(w io.Writer) {
+func Image10() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewNoiseTextureWith(NoiseTrilinearInterpolation)
@@ -18,5 +14,5 @@ func Image10(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image11(w io.Writer) {
+func Image11() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewNoiseTextureWith(NoiseTrilinearInterpolationWithHermitianSmoothing)
@@ -18,5 +14,5 @@ func Image11(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image12(w io.Writer) {
+func Image12() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewNoiseTexture(4)
@@ -18,5 +14,5 @@ func Image12(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
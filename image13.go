@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image13(w io.Writer) {
+func Image13() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewNoiseTextureWithGenerator(4, NewVectorPerlin())
@@ -18,5 +14,5 @@ func Image13(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
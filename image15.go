@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image15(w io.Writer) {
+func Image15() (Camera, Hittable) {
 	world := NewHittableList()
 
 	// To get the marble effect right, turbulence should use the unscaled point, that's why the 1/scale factor
@@ -19,5 +15,5 @@ func Image15(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
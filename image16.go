@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image16(w io.Writer) {
+func Image16() (Camera, Hittable) {
 	world := NewHittableList()
 
 	// Materials
@@ -28,5 +24,5 @@ func Image16(w io.Writer) {
 	cam.SetDefocusAngle(0)
 	cam.SetAspectRatio(1)
 
-	cam.Render(w, world)
+	return cam, world
 }
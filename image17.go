@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image17(w io.Writer) {
+func Image17() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewMarbleTexture(4)
@@ -21,5 +17,5 @@ func Image17(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetBackground(NewColor(0, 0, 0))
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image18(w io.Writer) {
+func Image18() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewMarbleTexture(4)
@@ -22,5 +18,5 @@ func Image18(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetBackground(NewColor(0, 0, 0))
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -1,14 +1,16 @@
 package main
 
-import (
-	"io"
-)
-
 func createEmptyCornellBox(world *HittableList) Material {
+	light := NewDiffuseLight(NewSolidColorTexture(NewColor(15, 15, 15)))
+
+	return createCornellBox(world, light)
+}
+
+// The walls of the Cornell box and its light, made of the given material
+func createCornellBox(world *HittableList, light Material) Material {
 	red := NewLambertianMaterial(NewColor(0.65, 0.05, 0.05))
 	green := NewLambertianMaterial(NewColor(0.12, 0.45, 0.15))
 	white := NewLambertianMaterial(NewColor(0.73, 0.73, 0.73))
-	light := NewDiffuseLight(NewSolidColorTexture(NewColor(15, 15, 15)))
 
 	world.Add(NewQuad(NewPoint3(555, 0, 0), NewVec3(0, 555, 0), NewVec3(0, 0, 555), green))
 	world.Add(NewQuad(NewPoint3(0, 0, 0), NewVec3(0, 555, 0), NewVec3(0, 0, 555), red))
@@ -21,7 +23,7 @@ func createEmptyCornellBox(world *HittableList) Material {
 }
 
 // Empty Cornell box
-func Image19(w io.Writer) {
+func Image19() (Camera, Hittable) {
 	world := NewHittableList()
 
 	createEmptyCornellBox(&world)
@@ -35,5 +37,5 @@ func Image19(w io.Writer) {
 	cam.SetImageWidth(400)
 	cam.SetRenderingParams(200, 50)
 
-	cam.Render(w, world)
+	return cam, world
 }
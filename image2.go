@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image2(w io.Writer) {
+func Image2() (Camera, Hittable) {
 	world := NewHittableList()
 
 	checker := NewBicolorCheckerTexture(3.2, NewColor(.2, .3, .1), NewColor(.9, .9, .9))
@@ -29,5 +25,5 @@ func Image2(w io.Writer) {
 
 	world_bvh := NewBhvTree(world)
 
-	cam.Render(w, world_bvh)
+	return cam, world_bvh
 }
@@ -1,9 +1,5 @@
 package main
 
-import (
-	"io"
-)
-
 // Returns the 3D box (six sides) that contains the two opposite vertices a and b
 func createBox(a, b Point3, mat Material) HittableList {
 	sides := NewHittableList()
@@ -23,7 +19,7 @@ func createBox(a, b Point3, mat Material) HittableList {
 }
 
 // Cornell box with two boxes
-func Image20(w io.Writer) {
+func Image20() (Camera, Hittable) {
 	world := NewHittableList()
 
 	white := createEmptyCornellBox(&world)
@@ -38,5 +34,5 @@ func Image20(w io.Writer) {
 	cam.SetBackground(NewColor(0, 0, 0))
 	cam.SetRenderingParams(200, 50)
 
-	cam.Render(w, world)
+	return cam, world
 }
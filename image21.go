@@ -1,11 +1,7 @@
 package main
 
-import (
-	"io"
-)
-
 // Cornell box with two rotated boxes
-func Image21(w io.Writer) {
+func Image21() (Camera, Hittable) {
 	world := NewHittableList()
 
 	white := createEmptyCornellBox(&world)
@@ -22,5 +18,5 @@ func Image21(w io.Writer) {
 	cam.SetBackground(NewColor(0, 0, 0))
 	cam.SetRenderingParams(200, 50)
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -1,11 +1,7 @@
 package main
 
-import (
-	"io"
-)
-
 // Cornell box with two boxes made of fog
-func Image22(w io.Writer) {
+func Image22() (Camera, Hittable) {
 	world := NewHittableList()
 
 	white := createEmptyCornellBox(&world)
@@ -30,5 +26,5 @@ func Image22(w io.Writer) {
 	cam.SetBackground(NewColor(0, 0, 0))
 	cam.SetRenderingParams(200, 50)
 
-	cam.Render(w, world)
+	return cam, world
 }
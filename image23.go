@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image23(w io.Writer) {
+func Image23() (Camera, Hittable) {
 	world := NewHittableList()
 
 	boxes1 := NewHittableList()
@@ -66,5 +62,5 @@ func Image23(w io.Writer) {
 	cam.SetBackground(NewColor(0, 0, 0))
 	cam.SetRenderingParams(250, 4) // Image in the book uses 10000, 40
 
-	cam.Render(w, world)
+	return cam, world
 }
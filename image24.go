@@ -0,0 +1,28 @@
+package main
+
+// Cornell box with objects placed by affine transforms: a sheared box, an ellipsoid and a tilted mirror
+func Image24() (Camera, Hittable) {
+	world := NewHittableList()
+
+	white := createEmptyCornellBox(&world)
+
+	box := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 330, 165), white)
+	world.Add(NewTransform(box, NewShearX(0.3, 0).Then(NewRotationY(15)).Then(NewTranslation(NewVec3(265, 0, 295)))))
+
+	blue := NewLambertianMaterial(NewColor(0.2, 0.3, 0.7))
+	ellipsoid := NewSphere(NewPoint3(0, 0, 0), 1, blue)
+	world.Add(NewTransform(ellipsoid, NewScaling(NewVec3(90, 45, 45)).Then(NewRotation(NewVec3(1, 1, 0), 30)).Then(NewTranslation(NewVec3(170, 90, 160)))))
+
+	mirror := createBox(NewPoint3(-60, -60, 0), NewPoint3(60, 60, 5), NewMetalMaterial(NewColor(0.9, 0.9, 0.9), 0))
+	world.Add(NewTransform(mirror, NewLookAt(NewPoint3(130, 300, 420), NewPoint3(330, 150, 0), NewVec3(0, 1, 0))))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(278, 278, -800))
+	cam.SetLookAt(NewPoint3(278, 278, 0))
+	cam.SetVerticalFieldOfView(40)
+	cam.SetBackground(NewColor(0, 0, 0))
+	cam.SetRenderingParams(200, 50)
+
+	return cam, world
+}
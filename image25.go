@@ -0,0 +1,32 @@
+package main
+
+// Cornell box with motion blur: while the shutter is open the tall box turns and slides, and a glass sphere shrinks to nothing
+func Image25() (Camera, Hittable) {
+	world := NewHittableList()
+
+	white := createEmptyCornellBox(&world)
+
+	// The start of the motion is the placement of the box of image 21
+	box1 := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 330, 165), white)
+	start := NewTRSFromMat4(NewRotationY(15).Then(NewTranslation(NewVec3(265, 0, 295))))
+	end := NewTRS(NewVec3(330, 0, 250), NewQuaternion(NewVec3(0, 1, 0), 60), NewVec3(1, 1, 1))
+	world.Add(NewAnimatedTransform(box1, start, end))
+
+	box2 := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 165, 165), white)
+	world.Add(NewTranslate(NewRotateY(box2, -18), NewVec3(130, 0, 65)))
+
+	sphere := NewSphere(NewPoint3(0, 0, 0), 1, NewDielectricMaterial(1.5))
+	world.Add(NewAnimatedTransform(sphere,
+		NewTRS(NewVec3(212, 245, 147), IdentityQuaternion(), NewVec3(80, 80, 80)),
+		NewTRS(NewVec3(212, 165, 147), IdentityQuaternion(), NewVec3(0, 0, 0))))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(278, 278, -800))
+	cam.SetLookAt(NewPoint3(278, 278, 0))
+	cam.SetVerticalFieldOfView(40)
+	cam.SetBackground(NewColor(0, 0, 0))
+	cam.SetRenderingParams(200, 50)
+
+	return cam, world
+}
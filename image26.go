@@ -0,0 +1,34 @@
+package main
+
+// A spinning propeller seen through a rolling shutter: every scanline is exposed a bit later than the one above,
+// so the blades look bent. The shutter opens and closes gradually (triangle curve), which softens the motion blur.
+func Image26() (Camera, Hittable) {
+	world := NewHittableList()
+
+	ground := NewLambertianMaterial(NewColor(0.4, 0.4, 0.4))
+	world.Add(NewSphere(NewPoint3(0, -1003, 0), 1000, ground))
+
+	red := NewLambertianMaterial(NewColor(0.7, 0.1, 0.1))
+	propeller := NewHittableList()
+	for _, angle := range []float64{0, 120, 240} {
+		blade := createBox(NewPoint3(-0.12, 0.2, -0.04), NewPoint3(0.12, 2, 0.04), red)
+		propeller.Add(NewTransform(blade, NewRotationZ(angle)))
+	}
+	propeller.Add(NewSphere(NewPoint3(0, 0, 0), 0.25, NewMetalMaterial(NewColor(0.8, 0.8, 0.8), 0.2)))
+
+	// Two turns while the scanlines are read
+	rotation := NewTrack(InterpolationLinear).Add(0, NewVec3(0, 0, 0)).Add(1, NewVec3(0, 0, -720))
+	world.Add(NewKeyframedTransform(propeller, nil, rotation, nil))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(0, 0, 8))
+	cam.SetLookAt(NewPoint3(0, 0, 0))
+	cam.SetVerticalFieldOfView(35)
+	cam.SetShutter(0, 0.02)
+	cam.SetShutterCurve(ShutterTriangle)
+	cam.SetRollingShutter(1)
+	cam.SetRenderingParams(100, 50)
+
+	return cam, world
+}
@@ -0,0 +1,23 @@
+package main
+
+// Cornell box with two rotated boxes seen from the inside through a 180 degrees fisheye lens
+func Image27() (Camera, Hittable) {
+	world := NewHittableList()
+
+	white := createEmptyCornellBox(&world)
+	box1 := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 330, 165), white)
+	world.Add(NewTranslate(NewRotateY(box1, 15), NewVec3(265, 0, 295)))
+	box2 := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 165, 165), white)
+	world.Add(NewTranslate(NewRotateY(box2, -18), NewVec3(130, 0, 65)))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(278, 200, 20))
+	cam.SetLookAt(NewPoint3(278, 200, 555))
+	cam.SetVerticalFieldOfView(180)
+	cam.SetProjection(ProjectionFisheye)
+	cam.SetBackground(NewColor(0, 0, 0))
+	cam.SetRenderingParams(200, 50)
+
+	return cam, world
+}
@@ -0,0 +1,28 @@
+package main
+
+// Isometric view of boxes and spheres on a checkered floor, with an orthographic projection
+func Image28() (Camera, Hittable) {
+	world := NewHittableList()
+
+	checker := NewBicolorCheckerTexture(0.5, NewColor(0.2, 0.3, 0.1), NewColor(0.9, 0.9, 0.9))
+	world.Add(NewQuad(NewPoint3(-4, 0, -4), NewVec3(8, 0, 0), NewVec3(0, 0, 8), NewTextureLambertianMaterial(checker)))
+
+	red := NewLambertianMaterial(NewColor(0.7, 0.15, 0.1))
+	blue := NewLambertianMaterial(NewColor(0.1, 0.2, 0.6))
+	world.Add(createBox(NewPoint3(-3, 0, -3), NewPoint3(-1, 2, -1), red))
+	world.Add(createBox(NewPoint3(1, 0, -3), NewPoint3(2, 3, -2), blue))
+	world.Add(createBox(NewPoint3(-3, 0, 1), NewPoint3(-2, 1, 3), blue))
+	world.Add(NewSphere(NewPoint3(1.5, 1, 1.5), 1, NewMetalMaterial(NewColor(0.8, 0.8, 0.8), 0.1)))
+	world.Add(NewSphere(NewPoint3(0, 0.5, 0), 0.5, NewDielectricMaterial(1.5)))
+
+	// The size of the view is the size of the perspective viewport at the distance of the "look at" point
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(10, 10, 10))
+	cam.SetLookAt(NewPoint3(0, 0.5, 0))
+	cam.SetVerticalFieldOfView(35)
+	cam.SetProjection(ProjectionOrthographic)
+	cam.SetRenderingParams(100, 50)
+
+	return cam, world
+}
@@ -0,0 +1,36 @@
+package main
+
+// Spheres at different depths seen by a side-by-side stereo camera, the scene is in meters. The middle sphere is at
+// the convergence distance, so it appears at the depth of the screen.
+func Image29() (Camera, Hittable) {
+	world := NewHittableList()
+
+	addStereoSpheres(&world)
+
+	cam := NewCamera()
+	cam.SetImageWidth(800) // Two images of 400 pixels
+	cam.SetLookFrom(NewPoint3(0, 1.6, 0))
+	cam.SetLookAt(NewPoint3(0, 0.5, -3))
+	cam.SetVerticalFieldOfView(50)
+	cam.SetStereo(StereoSideBySide, 0.064)
+	cam.SetConvergenceDistance(NewPoint3(0, 0.5, -3).Sub(NewPoint3(0, 1.6, 0)).Length())
+	cam.SetRenderingParams(100, 50)
+
+	return cam, NewBhvTree(world)
+}
+
+// Adds a checkered ground and spheres from 1.5 to 8 meters in front of the origin and around it
+func addStereoSpheres(world *HittableList) {
+	checker := NewBicolorCheckerTexture(0.5, NewColor(0.2, 0.3, 0.1), NewColor(0.9, 0.9, 0.9))
+	world.Add(NewQuad(NewPoint3(-20, 0, -20), NewVec3(40, 0, 0), NewVec3(0, 0, 40), NewTextureLambertianMaterial(checker)))
+
+	world.Add(NewSphere(NewPoint3(-0.6, 0.3, -1.5), 0.3, NewLambertianMaterial(NewColor(0.8, 0.2, 0.1))))
+	world.Add(NewSphere(NewPoint3(0, 0.5, -3), 0.5, NewMetalMaterial(NewColor(0.8, 0.8, 0.8), 0.05)))
+	world.Add(NewSphere(NewPoint3(1.5, 0.7, -5), 0.7, NewLambertianMaterial(NewColor(0.1, 0.2, 0.6))))
+	world.Add(NewSphere(NewPoint3(-2.5, 1, -8), 1, NewLambertianMaterial(NewColor(0.9, 0.7, 0.1))))
+
+	// Behind and beside the origin, for the panoramas
+	world.Add(NewSphere(NewPoint3(3, 0.5, 0), 0.5, NewDielectricMaterial(1.5)))
+	world.Add(NewSphere(NewPoint3(-2, 0.4, 2), 0.4, NewLambertianMaterial(NewColor(0.6, 0.1, 0.6))))
+	world.Add(NewSphere(NewPoint3(0.5, 0.8, 4), 0.8, NewMetalMaterial(NewColor(0.7, 0.6, 0.5), 0.2)))
+}
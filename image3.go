@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image3(w io.Writer) {
+func Image3() (Camera, Hittable) {
 	world := NewHittableList()
 
 	checker := NewBicolorCheckerTexture(3.2, NewColor(.2, .3, .1), NewColor(.9, .9, .9))
@@ -21,5 +17,5 @@ func Image3(w io.Writer) {
 
 	world_bvh := NewBhvTree(world)
 
-	cam.Render(w, world_bvh)
+	return cam, world_bvh
 }
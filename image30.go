@@ -0,0 +1,19 @@
+package main
+
+// Omni-directional stereo panorama of the spheres of image 29, the left eye on the top and the right eye on the bottom
+func Image30() (Camera, Hittable) {
+	world := NewHittableList()
+
+	addStereoSpheres(&world)
+
+	cam := NewCamera()
+	cam.SetImageWidth(800)
+	cam.SetAspectRatio(2)
+	cam.SetLookFrom(NewPoint3(0, 1.6, 0))
+	cam.SetLookAt(NewPoint3(0, 1.6, -1))
+	cam.SetProjection(ProjectionEquirectangular)
+	cam.SetStereo(StereoTopBottom, 0.064)
+	cam.SetRenderingParams(100, 50)
+
+	return cam, NewBhvTree(world)
+}
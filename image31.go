@@ -0,0 +1,31 @@
+package main
+
+// A sphere in front of out of focus lights, seen through a fast portrait lens with a hexagonal aperture. The scene is in
+// meters, the lights far from the image center show the "cat's eye" bokeh of the mechanical vignetting.
+// Try -aperture-mask with an image of a star or a heart to change the shape of the lights.
+func Image31() (Camera, Hittable) {
+	world := NewHittableList()
+
+	world.Add(NewQuad(NewPoint3(-20, 0, -30), NewVec3(40, 0, 0), NewVec3(0, 0, 40), NewLambertianMaterial(NewColor(0.3, 0.3, 0.3))))
+	world.Add(NewSphere(NewPoint3(0, 0.1, -2), 0.1, NewMetalMaterial(NewColor(0.8, 0.6, 0.2), 0.1)))
+	world.Add(NewSphere(NewPoint3(0, 5, 3), 1, NewDiffuseLight(NewSolidColorTexture(NewColor(10, 10, 10)))))
+
+	// Small lights scattered far behind the sphere
+	for i := 0; i < 40; i++ {
+		center := NewPoint3(RandomDoubleInInterval(-2, 2), RandomDoubleInInterval(0.1, 1.3), RandomDoubleInInterval(-14, -10))
+		color := NewColor(RandomDoubleInInterval(0.5, 1), RandomDoubleInInterval(0.4, 0.9), RandomDoubleInInterval(0.2, 0.6)).Mul(30)
+		world.Add(NewSphere(center, 0.03, NewDiffuseLight(NewSolidColorTexture(color))))
+	}
+
+	cam := NewCamera()
+	cam.SetLookFrom(NewPoint3(0, 0.25, 0))
+	cam.SetLookAt(NewPoint3(0, 0.15, -2))
+	cam.SetFocalLength(85)
+	cam.SetFStop(1.4)
+	cam.SetApertureBlades(6, 15)
+	cam.SetCatEye(0.5)
+	cam.SetBackground(NewColor(0.01, 0.01, 0.02))
+	cam.SetRenderingParams(200, 50)
+
+	return cam, NewBhvTree(world)
+}
@@ -0,0 +1,25 @@
+package main
+
+// A row of spheres with a shallow depth of field, the camera focuses automatically on the blue sphere
+func Image32() (Camera, Hittable) {
+	world := NewHittableList()
+
+	checker := NewBicolorCheckerTexture(1, NewColor(0.2, 0.3, 0.1), NewColor(0.9, 0.9, 0.9))
+	world.Add(NewQuad(NewPoint3(-20, 0, -40), NewVec3(40, 0, 0), NewVec3(0, 0, 42), NewTextureLambertianMaterial(checker)))
+
+	colors := []Color{NewColor(0.8, 0.2, 0.1), NewColor(0.9, 0.7, 0.1), NewColor(0.2, 0.6, 0.2), NewColor(0.1, 0.3, 0.8), NewColor(0.6, 0.1, 0.6)}
+	for i, color := range colors {
+		world.Add(NewSphere(NewPoint3(-2+float64(i), 0.5, -3-3*float64(i)), 0.5, NewLambertianMaterial(color)))
+	}
+
+	world_bvh := NewBhvTree(world)
+
+	cam := NewCamera()
+	cam.SetLookFrom(NewPoint3(0, 1, 1))
+	cam.SetLookAt(NewPoint3(0, 0.5, -8))
+	cam.SetVerticalFieldOfView(30)
+	cam.SetDefocusAngle(1.5)
+	cam.Autofocus(world_bvh, 0.58, 0.47)
+
+	return cam, world_bvh
+}
@@ -0,0 +1,52 @@
+package main
+
+// Adds a floor and rows of spheres, one for every material, the first row is the farthest from the camera of
+// newMaterialCamera. The rows are centered on the z axis.
+func addMaterialSpheres(world *HittableList, rows ...[]Material) {
+	checker := NewBicolorCheckerTexture(1, NewColor(0.2, 0.2, 0.2), NewColor(0.8, 0.8, 0.8))
+	world.Add(NewQuad(NewPoint3(-20, 0, -20), NewVec3(40, 0, 0), NewVec3(0, 0, 40), NewTextureLambertianMaterial(checker)))
+
+	for r, row := range rows {
+		z := 1.3 * (float64(r) - float64(len(rows)-1)/2)
+
+		for i, mat := range row {
+			x := 1.2 * (float64(i) - float64(len(row)-1)/2)
+			world.Add(NewSphere(NewPoint3(x, 0.5, z), 0.5, mat))
+		}
+	}
+}
+
+// Returns a camera that looks at the spheres of addMaterialSpheres from above
+func newMaterialCamera() Camera {
+	cam := NewCamera()
+	cam.SetLookFrom(NewPoint3(0, 3, 6))
+	cam.SetLookAt(NewPoint3(0, 0.3, 0))
+	cam.SetVerticalFieldOfView(35)
+	cam.SetRenderingParams(200, 50)
+
+	return cam
+}
+
+// Metals with a microfacet surface: gold, copper, aluminium and silver, polished on the back row and rough on the
+// middle row, while the front row goes from polished to rough aluminium (with a checkered roughness in the second sphere), then brushed (anisotropic) aluminium
+func Image33() (Camera, Hittable) {
+	world := NewHittableList()
+
+	metals := []ConductorIOR{Gold, Copper, Aluminium, Silver}
+	var polished, rough []Material
+	for _, ior := range metals {
+		polished = append(polished, NewConductorMaterial(ior, 0.05))
+		rough = append(rough, NewConductorMaterial(ior, 0.4))
+	}
+
+	roughness := NewBicolorCheckerTexture(6, NewColor(0.05, 0.05, 0.05), NewColor(0.5, 0.5, 0.5))
+	front := []Material{
+		NewConductorMaterial(Aluminium, 0.2),
+		NewTextureConductorMaterial(Aluminium, roughness),
+		NewConductorMaterial(Aluminium, 0.7),
+		NewAnisotropicConductorMaterial(Aluminium, 0.3, 0.9)}
+
+	addMaterialSpheres(&world, polished, rough, front)
+
+	return newMaterialCamera(), NewBhvTree(world)
+}
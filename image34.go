@@ -0,0 +1,27 @@
+package main
+
+// Rough dielectrics: glass from smooth to frosted on the back row, tinted glass on the front row, where the thick
+// spheres are darker than the thin slab and the last sphere is frosted in stripes
+func Image34() (Camera, Hittable) {
+	world := NewHittableList()
+
+	var frosted []Material
+	for _, roughness := range []float64{0, 0.1, 0.3, 0.6} {
+		frosted = append(frosted, NewRoughDielectricMaterial(1.5, roughness))
+	}
+
+	smooth := NewSolidColorTexture(Color{})
+	stripes := NewBicolorCheckerTexture(8, NewColor(0, 0, 0), NewColor(0.4, 0.4, 0.4))
+	tinted := []Material{
+		NewTintedRoughDielectricMaterial(1.5, smooth, NewColor(0.2, 0.8, 0.3), 1),
+		NewTintedRoughDielectricMaterial(1.5, smooth, NewColor(0.9, 0.4, 0.1), 0.5),
+		NewTintedRoughDielectricMaterial(1.33, NewSolidColorTexture(NewColor(0.2, 0.2, 0.2)), NewColor(0.3, 0.5, 0.9), 1),
+		NewTintedRoughDielectricMaterial(1.5, stripes, NewColor(0.8, 0.8, 0.8), 1)}
+
+	addMaterialSpheres(&world, frosted, tinted)
+
+	// A thin slab of the first tinted glass, much lighter than the sphere
+	world.Add(createBox(NewPoint3(-2, 0, 1.2), NewPoint3(2, 0.25, 1.3), tinted[0]))
+
+	return newMaterialCamera(), NewBhvTree(world)
+}
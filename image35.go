@@ -0,0 +1,21 @@
+package main
+
+// Dispersive materials in front of a checkered wall: the edges of the black and white squares seen through them get
+// colored fringes, stronger for the flint glass and the diamond (front row, rough on the right)
+func Image35() (Camera, Hittable) {
+	world := NewHittableList()
+
+	clear := []Material{NewDispersiveMaterial(Water, 0), NewDispersiveMaterial(FusedSilica, 0), NewDispersiveMaterial(CrownGlass, 0)}
+	dense := []Material{NewDispersiveMaterial(FlintGlass, 0), NewDispersiveMaterial(Diamond, 0), NewDispersiveMaterial(FlintGlass, 0.2)}
+
+	addMaterialSpheres(&world, clear, dense)
+
+	checker := NewBicolorCheckerTexture(4, NewColor(0.02, 0.02, 0.02), NewColor(0.9, 0.9, 0.9))
+	world.Add(NewQuad(NewPoint3(-10, 0, -2), NewVec3(20, 0, 0), NewVec3(0, 10, 0), NewTextureLambertianMaterial(checker)))
+
+	cam := newMaterialCamera()
+	cam.SetLookFrom(NewPoint3(0, 2.5, 6))
+	cam.SetLookAt(NewPoint3(0, 0.6, 0))
+
+	return cam, NewBhvTree(world)
+}
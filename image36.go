@@ -0,0 +1,31 @@
+package main
+
+// Cornell box lit by lights with emission spectra: a black body at 6500 K (daylight) on the ceiling, one at 1900 K
+// (a candle) on the right and a low pressure sodium lamp on the left, which emits a single yellow wavelength so the
+// colors near it disappear. Render it with -spectral: RGB rays only see the RGB colors of the lights.
+func Image36() (Camera, Hittable) {
+	world := NewHittableList()
+
+	white := createCornellBox(&world, NewBlackbodyLight(6500, 10))
+
+	sodium := NewSampledSpectrum([]float64{585, 589, 593}, []float64{0, 1, 0})
+	world.Add(NewSphere(NewPoint3(90, 120, 200), 40, NewSpectralLight(sodium, 20)))
+	world.Add(NewSphere(NewPoint3(465, 120, 200), 40, NewBlackbodyLight(1900, 20)))
+
+	box := createBox(NewPoint3(0, 0, 0), NewPoint3(165, 330, 165), white)
+	world.Add(NewTranslate(NewRotateY(box, 15), NewVec3(265, 0, 295)))
+
+	world.Add(NewSphere(NewPoint3(150, 60, 150), 60, NewLambertianMaterial(NewColor(0.1, 0.2, 0.7))))
+	world.Add(NewSphere(NewPoint3(300, 60, 100), 60, NewLambertianMaterial(NewColor(0.1, 0.6, 0.2))))
+	world.Add(NewSphere(NewPoint3(430, 60, 120), 50, NewDispersiveMaterial(Diamond, 0)))
+
+	cam := NewCamera()
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(278, 278, -800))
+	cam.SetLookAt(NewPoint3(278, 278, 0))
+	cam.SetVerticalFieldOfView(40)
+	cam.SetBackground(NewColor(0, 0, 0))
+	cam.SetRenderingParams(200, 50)
+
+	return cam, world
+}
@@ -0,0 +1,65 @@
+package main
+
+// Principled materials: on the back row a gold metal, a red plastic, glass and a blue car paint with a clear coat,
+// in the middle row the same kind of materials converted from MTL files (the second one is a classic Phong material
+// and the last one glows), on the front row materials converted from glTF files: velvet with sheen, a checkered
+// base color texture, a checkered metallic-roughness texture and an emissive material
+func Image37() (Camera, Hittable) {
+	world := NewHittableList()
+
+	solid := func(value float64) Texture {
+		return NewSolidColorTexture(NewColor(value, value, value))
+	}
+
+	metal := NewPrincipledParams()
+	metal.BaseColor, metal.Metallic, metal.Roughness = NewSolidColorTexture(NewColor(1, 0.78, 0.34)), solid(1), solid(0.25)
+
+	plastic := NewPrincipledParams()
+	plastic.BaseColor, plastic.Roughness = NewSolidColorTexture(NewColor(0.7, 0.05, 0.05)), solid(0.3)
+
+	glass := NewPrincipledParams()
+	glass.BaseColor, glass.Transmission, glass.Roughness = NewSolidColorTexture(NewColor(1, 1, 1)), solid(1), solid(0)
+
+	paint := NewPrincipledParams()
+	paint.BaseColor, paint.Roughness = NewSolidColorTexture(NewColor(0.05, 0.1, 0.5)), solid(0.6)
+	paint.Clearcoat, paint.ClearcoatRoughness = 1, 0.05
+
+	principled := []Material{NewPrincipledMaterial(metal), NewPrincipledMaterial(plastic), NewPrincipledMaterial(glass), NewPrincipledMaterial(paint)}
+
+	mtlMetal := NewMtlParams()
+	mtlMetal.Kd, mtlMetal.Pm, mtlMetal.Pr = NewColor(0.95, 0.64, 0.54), 1, 0.35
+
+	phong := NewMtlParams()
+	phong.Kd, phong.Ns = NewColor(0.1, 0.5, 0.1), 200
+
+	mtlGlass := NewMtlParams()
+	mtlGlass.Kd, mtlGlass.D, mtlGlass.Ni, mtlGlass.Ns = NewColor(0.8, 0.9, 1), 0, 1.5, 1000
+
+	lamp := NewMtlParams()
+	lamp.Kd, lamp.Ke = NewColor(0.1, 0.1, 0.1), NewColor(1.2, 0.8, 0.3)
+
+	mtl := []Material{NewMtlMaterial(mtlMetal), NewMtlMaterial(phong), NewMtlMaterial(mtlGlass), NewMtlMaterial(lamp)}
+
+	velvet := NewGltfParams()
+	velvet.BaseColorFactor, velvet.MetallicFactor, velvet.SheenColorFactor = NewColor(0.3, 0.02, 0.1), 0, NewColor(1, 0.6, 0.8)
+
+	// The colors of the textures are sRGB, as they would be in an image file
+	checker := NewGltfParams()
+	checker.BaseColorTexture = NewBicolorCheckerTexture(8, NewColor(0.9, 0.6, 0.1), NewColor(0.2, 0.4, 0.8))
+	checker.MetallicFactor, checker.RoughnessFactor = 0, 0.5
+
+	// Polished metal squares (roughness in the green channel and metallic in the blue channel) and rough plastic ones
+	patches := NewGltfParams()
+	patches.BaseColorFactor = NewColor(0.9, 0.9, 0.9)
+	patches.MetallicRoughnessTexture = NewBicolorCheckerTexture(8, NewColor(0, 0.1, 1), NewColor(0, 0.8, 0))
+
+	glow := NewGltfParams()
+	glow.BaseColorFactor, glow.MetallicFactor = NewColor(0.1, 0.1, 0.1), 0
+	glow.EmissiveFactor, glow.EmissiveStrength = NewColor(0.1, 0.4, 1), 0.8
+
+	gltf := []Material{NewGltfMaterial(velvet), NewGltfMaterial(checker), NewGltfMaterial(patches), NewGltfMaterial(glow)}
+
+	addMaterialSpheres(&world, principled, mtl, gltf)
+
+	return newMaterialCamera(), NewBhvTree(world)
+}
@@ -0,0 +1,33 @@
+package main
+
+// Layered materials: on the back row mixes of two materials, copper with some rust, gold and red paint in checkered
+// patches, marble veins of aluminium and half diffuse and half polished silver, on the front row the same diffuse
+// colors under a clear coat, polished or rough, then a coat whose roughness is checkered and a coated gold
+func Image38() (Camera, Hittable) {
+	world := NewHittableList()
+
+	rust := NewLambertianMaterial(NewColor(0.45, 0.15, 0.05))
+	red := NewLambertianMaterial(NewColor(0.6, 0.05, 0.05))
+	blue := NewLambertianMaterial(NewColor(0.05, 0.1, 0.5))
+
+	patches := NewBicolorCheckerTexture(6, NewColor(0, 0, 0), NewColor(1, 1, 1))
+	veins := NewMarbleTexture(4)
+
+	mixes := []Material{
+		NewMixMaterial(NewConductorMaterial(Copper, 0.2), rust, 0.3),
+		NewTextureMixMaterial(NewConductorMaterial(Gold, 0.1), red, patches),
+		NewTextureMixMaterial(NewLambertianMaterial(NewColor(0.1, 0.1, 0.1)), NewConductorMaterial(Aluminium, 0.1), veins),
+		NewMixMaterial(NewLambertianMaterial(NewColor(0.8, 0.8, 0.8)), NewConductorMaterial(Silver, 0), 0.5)}
+
+	roughness := NewBicolorCheckerTexture(6, NewColor(0, 0, 0), NewColor(0.5, 0.5, 0.5))
+
+	coats := []Material{
+		NewCoatedMaterial(red, 1.5, 0),
+		NewCoatedMaterial(blue, 1.5, 0.4),
+		NewTextureCoatedMaterial(blue, 1.5, roughness),
+		NewCoatedMaterial(NewConductorMaterial(Gold, 0.4), 1.5, 0)}
+
+	addMaterialSpheres(&world, mixes, coats)
+
+	return newMaterialCamera(), NewBhvTree(world)
+}
@@ -0,0 +1,20 @@
+package main
+
+// Rough matte materials: the back row is Lambertian, the middle and the front rows are Oren-Nayar materials with
+// roughness 0.5 and 1 and the same colors, that look flatter and lighter towards the edges
+func Image39() (Camera, Hittable) {
+	world := NewHittableList()
+
+	colors := []Color{NewColor(0.8, 0.8, 0.8), NewColor(0.7, 0.3, 0.15), NewColor(0.3, 0.5, 0.2), NewColor(0.3, 0.3, 0.6)}
+
+	var lambertian, rough, roughest []Material
+	for _, c := range colors {
+		lambertian = append(lambertian, NewLambertianMaterial(c))
+		rough = append(rough, NewOrenNayarMaterial(c, 0.5))
+		roughest = append(roughest, NewOrenNayarMaterial(c, 1))
+	}
+
+	addMaterialSpheres(&world, lambertian, rough, roughest)
+
+	return newMaterialCamera(), NewBhvTree(world)
+}
@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image5(w io.Writer) {
+func Image5() (Camera, Hittable) {
 	earthTexture := NewImageTexture("earthmap.jpg")
 	earthSurface := NewTextureLambertianMaterial(earthTexture)
 	globe := NewSphere(NewPoint3(0, 0, 0), 2, earthSurface)
@@ -17,5 +13,5 @@ func Image5(w io.Writer) {
 	world := NewHittableList()
 	world.Add(globe)
 
-	cam.Render(w, world)
+	return cam, world
 }
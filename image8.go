@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image8(w io.Writer) {
+func Image8() (Camera, Hittable) {
 	world := NewHittableList()
 
 	checker := NewRandomBlockTexture(3.2)
@@ -18,5 +14,5 @@ func Image8(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -1,10 +1,6 @@
 package main
 
-import (
-	"io"
-)
-
-func Image9(w io.Writer) {
+func Image9() (Camera, Hittable) {
 	world := NewHittableList()
 
 	noise := NewNoiseTextureWith(NoiseNoInterpolation)
@@ -18,5 +14,5 @@ func Image9(w io.Writer) {
 	cam.SetVerticalFieldOfView(20)
 	cam.SetDefocusAngle(0)
 
-	cam.Render(w, world)
+	return cam, world
 }
@@ -15,3 +15,35 @@ func (dl DiffuseLight) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scat
 func (dl DiffuseLight) Emitted(u, v float64, p Point3) Color {
 	return dl.emit.Value(u, v, p)
 }
+
+// A light with an emission spectrum (e.g. a black body, or the measured spectrum of a lamp), scaled so that the
+// luminance of its RGB color is intensity. RGB rays see its RGB color.
+type SpectralLight struct {
+	spectrum Spectrum
+	scale    float64
+	rgb      Color
+}
+
+func NewSpectralLight(spectrum Spectrum, intensity float64) SpectralLight {
+	rgb := SpectrumToRGB(spectrum)
+	scale := intensity / luminance(rgb)
+
+	return SpectralLight{spectrum: spectrum, scale: scale, rgb: rgb.Mul(scale)}
+}
+
+// A light with the color of a black body at the given temperature in kelvin
+func NewBlackbodyLight(temperature, intensity float64) SpectralLight {
+	return NewSpectralLight(NewBlackbodySpectrum(temperature), intensity)
+}
+
+func (sl SpectralLight) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	return false
+}
+
+func (sl SpectralLight) Emitted(u, v float64, p Point3) Color {
+	return sl.rgb
+}
+
+func (sl SpectralLight) EmittedSpectral(u, v float64, p Point3, wavelengths Vec3) Color {
+	return NewColor(sl.spectrum.At(wavelengths.X), sl.spectrum.At(wavelengths.Y), sl.spectrum.At(wavelengths.Z)).Mul(sl.scale)
+}
@@ -1,49 +1,626 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const OutputFilename = "out.ppm"
 
+// Images that are not ray traced are written directly
 type Renderer func(w io.Writer)
 
+// Ray traced images are scenes, made of a camera and a world
+type Scene func() (Camera, Hittable)
+
+var renderers = map[int]Renderer{4: Image4, 6: Image6, 7: Image7}
+
+var scenes = map[int]Scene{
+	1: Image1, 2: Image2, 3: Image3, 5: Image5, 8: Image8, 9: Image9, 10: Image10, 11: Image11, 12: Image12, 13: Image13,
+	14: Image14, 15: Image15, 16: Image16, 17: Image17, 18: Image18, 19: Image19, 20: Image20, 21: Image21, 22: Image22, 23: Image23,
+	24: Image24, 25: Image25, 26: Image26, 27: Image27, 28: Image28, 29: Image29,
+	30: Image30, 31: Image31, 32: Image32, 33: Image33, 34: Image34, 35: Image35, 36: Image36, 37: Image37, 38: Image38, 39: Image39}
+
+// Animations are rendered to a sequence of numbered images
+var animations = map[int]AnimatedScene{1: Animation1}
+
+// Commands other than rendering an image
+var commands = map[string]func(args []string) error{
+	"merge":  runMerge,
+	"worker": runWorker,
+}
+
+type Options struct {
+	output          string
+	seed            int64
+	sampleSeed      int64
+	samplesPerPixel int
+	progressive     bool
+	checkpoint      string
+	checkpointEvery time.Duration
+	timeBudget      time.Duration
+	resume          bool
+	workers         int
+	animation       int
+	frames          string
+	fps             float64
+	shutterAngle    float64
+	crop            string
+	composite       bool
+	trace           string
+	traceSamples    int
+	aov             string
+	denoise         bool
+	denoiseSteps    int
+	clamp           float64
+	fireflies       float64
+	robust          int
+	spectral        bool
+	apertureMask    string
+}
+
 func main() {
-	renderers := []Renderer{Image1, Image2, Image3, Image4, Image5, Image6, Image7, Image8, Image9, Image10, Image11, Image12, Image13, Image14, Image15, Image16, Image17, Image18, Image19, Image20, Image21, Image22, Image23}
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	options := Options{}
+
+	flag.StringVar(&options.output, "o", OutputFilename, "output file")
+	flag.Int64Var(&options.seed, "seed", time.Now().UnixNano(), "seed of the random generator, the same seed always builds the same scene")
+	flag.Int64Var(&options.sampleSeed, "sample-seed", time.Now().UnixNano()+1, "seed of the random generator once the scene is built")
+	flag.IntVar(&options.samplesPerPixel, "spp", 0, "samples per pixel (default is the image setting)")
+	flag.BoolVar(&options.progressive, "progressive", false, "render progressively, one sample per pixel at a time")
+	flag.StringVar(&options.checkpoint, "checkpoint", "", "save the progressive render state to this file (implies -progressive)")
+	flag.DurationVar(&options.checkpointEvery, "checkpoint-every", time.Minute, "how often the checkpoint is saved")
+	flag.DurationVar(&options.timeBudget, "time", 0, "render progressively for this time, e.g. 20m (the number of samples is ignored)")
+	flag.BoolVar(&options.resume, "resume", false, "resume the progressive render saved in the checkpoint file")
+	flag.IntVar(&options.workers, "workers", 0, "render with this number of worker processes")
+	flag.IntVar(&options.animation, "animation", 0, "render the frames of this animation instead of an image")
+	flag.StringVar(&options.frames, "frames", "0-47", "range of frames of the animation, e.g. 0-47 or 12")
+	flag.Float64Var(&options.fps, "fps", 24, "frames per second of the animation")
+	flag.Float64Var(&options.shutterAngle, "shutter-angle", 180, "fraction of the frame time the shutter is open, in degrees (360 is the whole frame)")
+	flag.StringVar(&options.crop, "crop", "", "render only the window x,y,width,height of the image, e.g. 100,50,64,64")
+	flag.BoolVar(&options.composite, "composite", false, "paste the -crop window into the existing output file instead of writing a small image")
+	flag.StringVar(&options.trace, "trace", "", "write every bounce of the samples of pixel x,y instead of rendering, e.g. 120,80")
+	flag.IntVar(&options.traceSamples, "trace-samples", 1, "number of samples written by -trace")
+	flag.StringVar(&options.aov, "aov", "", "also write these auxiliary outputs, e.g. depth,normal or all ("+strings.Join(AovNames, ",")+")")
+	flag.BoolVar(&options.denoise, "denoise", false, "remove the noise from the rendered image")
+	flag.IntVar(&options.denoiseSteps, "denoise-iterations", 5, "iterations of the denoising filter, each one doubles its size")
+	flag.Float64Var(&options.clamp, "clamp", 0, "limit the brightness of every sample to this value, e.g. 10")
+	flag.Float64Var(&options.fireflies, "fireflies", 0, "replace the pixels brighter than their neighbors by this many standard errors, e.g. 5")
+	flag.IntVar(&options.robust, "robust", 0, "make every pixel the median of the averages of this number of groups of samples, e.g. 5")
+	flag.BoolVar(&options.spectral, "spectral", false, "render with wavelengths instead of RGB colors")
+	flag.StringVar(&options.apertureMask, "aperture-mask", "", "PNG or JPEG image of the shape of the lens aperture, for scenes with depth of field")
+	flag.Parse()
+
+	if options.animation != 0 {
+		if err := runAnimation(options); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	imageNo := 23
 
-	if len(os.Args) == 2 {
-		imageNo, _ = strconv.Atoi(os.Args[1])
-	} else {
+	if flag.NArg() == 1 {
+		imageNo, _ = strconv.Atoi(flag.Arg(0))
+	} else if !options.resume {
 		fmt.Fprintln(os.Stderr, "No image number specified, default is", imageNo)
 	}
 
-	if imageNo >= 1 && imageNo <= 23 {
-		renderer := renderers[imageNo-1]
+	if err := run(imageNo, options); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(imageNo int, options Options) error {
+	var checkpoint *Checkpoint
+
+	if options.resume {
+		if options.checkpoint == "" {
+			return fmt.Errorf("-resume needs a -checkpoint file")
+		}
+
+		var err error
+		if checkpoint, err = LoadCheckpoint(options.checkpoint); err != nil {
+			return err
+		}
+
+		if flag.NArg() == 1 && imageNo != checkpoint.Scene.ImageNo {
+			return fmt.Errorf("checkpoint %s is for image no. %d", options.checkpoint, checkpoint.Scene.ImageNo)
+		}
+
+		// Samples with a different clamp would be mixed with the ones of the checkpoint
+		if options.clamp != 0 && options.clamp != checkpoint.Clamp {
+			return fmt.Errorf("checkpoint %s was rendered with -clamp %g", options.checkpoint, checkpoint.Clamp)
+		}
+
+		imageNo, options.seed, options.sampleSeed = checkpoint.Scene.ImageNo, checkpoint.Scene.Seed, checkpoint.SampleSeed
+		options.apertureMask, options.spectral = checkpoint.Scene.ApertureMask, checkpoint.Scene.Spectral
+		options.clamp = checkpoint.Clamp
+	}
+
+	renderer, isRenderer := renderers[imageNo]
+	_, isScene := scenes[imageNo]
+
+	if !isRenderer && !isScene {
+		return fmt.Errorf("Image number must be between 1 and %d", len(renderers)+len(scenes))
+	}
 
-		fmt.Fprintln(os.Stderr, "Rendering image no.", imageNo, "on file", OutputFilename)
+	fmt.Fprintln(os.Stderr, "Rendering image no.", imageNo, "on file", options.output)
 
-		start := time.Now()
+	start := time.Now()
 
-		f, err := os.Create(OutputFilename)
+	if isRenderer {
+		SeedRandom(options.seed)
+
+		f, err := os.Create(options.output)
 
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		defer f.Close()
 
 		renderer(f)
+	} else {
+		var aovs []string
+		var err error
+
+		if options.aov != "" {
+			if aovs, err = parseAovs(options.aov); err != nil {
+				return err
+			}
+		}
 
-		elapsed := time.Since(start)
+		identity := SceneIdentity{ImageNo: imageNo, Seed: options.seed, ApertureMask: options.apertureMask, Spectral: options.spectral}
 
-		fmt.Fprintln(os.Stderr, "Done in", elapsed)
-	} else {
-		fmt.Fprintln(os.Stderr, "Image number must be between 1 and 23")
+		cam, world, err := buildScene(identity, options.samplesPerPixel)
+
+		if err != nil {
+			return err
+		}
+
+		identity.Width, identity.Height = cam.imageWidth, cam.imageHeight
+
+		if options.trace != "" {
+			pixel, err := parseInts(options.trace, 2)
+
+			if err != nil || pixel[0] < 0 || pixel[0] >= cam.imageWidth || pixel[1] < 0 || pixel[1] >= cam.imageHeight {
+				return fmt.Errorf("invalid pixel to trace: %s", options.trace)
+			}
+
+			if options.traceSamples < 1 {
+				return fmt.Errorf("-trace-samples must be at least 1")
+			}
+
+			SeedRandom(options.sampleSeed)
+			cam.TracePixel(os.Stdout, world, pixel[0], pixel[1], options.traceSamples)
+
+			return nil
+		}
+
+		if options.workers > 0 && (options.clamp > 0 || options.fireflies > 0 || options.robust > 0) {
+			return fmt.Errorf("-clamp, -fireflies and -robust can't be used with distributed rendering")
+		}
+
+		cam.SetSampleClamp(options.clamp)
+
+		var recorded []string // The auxiliary outputs recorded by the film
+
+		if options.aov != "" || options.denoise || options.fireflies > 0 {
+			if options.workers > 0 || options.composite {
+				return fmt.Errorf("-aov, -denoise and -fireflies can't be used with distributed rendering or -composite")
+			}
+
+			// The filters need some auxiliary outputs, even if they are not written
+			var needed []string
+			if options.denoise {
+				needed = append(needed, DenoiseAovs...)
+			}
+			if options.fireflies > 0 {
+				needed = append(needed, AovVariance)
+			}
+
+			recorded = slices.Clone(aovs)
+			for _, name := range needed {
+				if !slices.Contains(recorded, name) {
+					recorded = append(recorded, name)
+				}
+			}
+
+			cam.SetAovs(recorded...)
+			cam.Initialize()
+
+			if slices.Contains(recorded, AovMaterialID) {
+				cam.NumberMaterials(world)
+			}
+		}
+
+		// Creates the film the image is rendered on
+		newFilm := func(width, height int) *Film {
+			film := NewFilm(width, height)
+			if options.robust > 0 {
+				film.SetRobustGroups(options.robust)
+			}
+			return film
+		}
+
+		var crop []int
+
+		if options.crop != "" {
+			if crop, err = parseInts(options.crop, 4); err != nil || crop[0] < 0 || crop[1] < 0 || crop[2] <= 0 || crop[3] <= 0 ||
+				crop[0]+crop[2] > cam.imageWidth || crop[1]+crop[3] > cam.imageHeight {
+				return fmt.Errorf("invalid crop window %s, the image is %dx%d", options.crop, cam.imageWidth, cam.imageHeight)
+			}
+
+			if options.progressive || options.checkpoint != "" || options.timeBudget > 0 || options.workers > 0 {
+				return fmt.Errorf("-crop can't be used with progressive or distributed rendering")
+			}
+		} else if options.composite {
+			return fmt.Errorf("-composite needs a -crop window")
+		}
+
+		// Stop rendering on Ctrl-C, the partial image is still written
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		var film *Film
+		var renderErr error
+
+		if options.progressive || options.checkpoint != "" || options.timeBudget > 0 {
+			if checkpoint == nil {
+				checkpoint = &Checkpoint{Scene: identity, SampleSeed: options.sampleSeed, Film: newFilm(cam.imageWidth, cam.imageHeight),
+					Clamp: options.clamp, Layers: recorded}
+			} else if checkpoint.Scene != identity {
+				return fmt.Errorf("checkpoint %s doesn't match the scene", options.checkpoint)
+			} else if !sameNames(checkpoint.Layers, recorded) {
+				return fmt.Errorf("checkpoint %s records the AOVs %s, resume it with the same -aov, -denoise and -fireflies options",
+					options.checkpoint, strings.Join(checkpoint.Layers, ","))
+			} else {
+				fmt.Fprintln(os.Stderr, "Resuming from pass", checkpoint.Passes)
+			}
+
+			film = checkpoint.Film
+			renderErr = cam.RenderProgressive(ctx, world, checkpoint, ProgressiveOptions{
+				Passes:       cam.samplesPerPixel,
+				TimeBudget:   options.timeBudget,
+				Checkpoint:   options.checkpoint,
+				SaveInterval: options.checkpointEvery,
+				Progress:     PrintProgress})
+		} else if options.workers > 0 {
+			film = NewFilm(cam.imageWidth, cam.imageHeight)
+			renderErr = RenderDistributed(ctx, film, identity, cam.samplesPerPixel, options.sampleSeed, options.workers, StartWorkerProcess, PrintProgress)
+		} else if crop != nil {
+			SeedRandom(options.sampleSeed)
+
+			film = newFilm(crop[2], crop[3])
+			renderErr = cam.RenderCrop(ctx, world, film, crop[0], crop[1], cam.samplesPerPixel, PrintProgress)
+		} else {
+			SeedRandom(options.sampleSeed)
+
+			film = newFilm(cam.imageWidth, cam.imageHeight)
+			renderErr = cam.RenderContext(ctx, world, film, cam.samplesPerPixel, PrintProgress)
+		}
+
+		if renderErr != nil && renderErr != context.Canceled {
+			return renderErr
+		} else if renderErr != nil {
+			fmt.Fprintln(os.Stderr, "Render interrupted, writing the partial image")
+		}
+
+		film.Scene, film.SampleSeeds = identity, []int64{options.sampleSeed}
+
+		if options.composite {
+			frame, err := ReadImage(options.output)
+
+			if err != nil {
+				return err
+			}
+
+			if frame.Width != cam.imageWidth || frame.Height != cam.imageHeight {
+				return fmt.Errorf("%s is %dx%d, the image is %dx%d", options.output, frame.Width, frame.Height, cam.imageWidth, cam.imageHeight)
+			}
+
+			if frame.Scene != (SceneIdentity{}) && frame.Scene != identity {
+				return fmt.Errorf("%s is a render of another scene, %+v", options.output, frame.Scene)
+			}
+
+			// Images only keep the colors of the pixels, while a .film keeps the samples of the crop, and its seed
+			if strings.ToLower(filepath.Ext(options.output)) != ".film" {
+				film = film.ColorFilm()
+			} else {
+				frame.SampleSeeds = append(frame.SampleSeeds, options.sampleSeed)
+			}
+
+			if err := frame.CopyTile(film, crop[0], crop[1]); err != nil {
+				return fmt.Errorf("cannot paste the crop window into %s: %w", options.output, err)
+			}
+
+			film = frame
+		}
+
+		if options.fireflies > 0 {
+			film = FilterFireflies(film, options.fireflies)
+		}
+
+		if options.denoise {
+			film = Denoise(film, options.denoiseSteps)
+		}
+
+		if err := WriteFilm(options.output, film); err != nil {
+			return err
+		}
+
+		if err := WriteLayers(options.output, film, aovs); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, "Rendered with", film.Samples(), "samples per pixel")
+	}
+
+	elapsed := time.Since(start)
+
+	fmt.Fprintln(os.Stderr, "Done in", elapsed)
+
+	return nil
+}
+
+// Builds a scene from its identity and initializes its camera, an identity without image size matches any size
+func buildScene(identity SceneIdentity, samplesPerPixel int) (Camera, Hittable, error) {
+	scene, ok := scenes[identity.ImageNo]
+
+	if !ok {
+		return Camera{}, nil, fmt.Errorf("image no. %d is not a ray traced scene", identity.ImageNo)
+	}
+
+	SeedRandom(identity.Seed)
+
+	cam, world := scene()
+
+	if samplesPerPixel > 0 {
+		cam.SetSamplesPerPixel(samplesPerPixel)
+	}
+
+	cam.SetSpectral(identity.Spectral)
+
+	if identity.ApertureMask != "" {
+		if err := cam.SetApertureMask(identity.ApertureMask); err != nil {
+			return Camera{}, nil, err
+		}
+	}
+
+	cam.Initialize()
+
+	if identity.Width != 0 && (identity.Width != cam.imageWidth || identity.Height != cam.imageHeight) {
+		return Camera{}, nil, fmt.Errorf("image no. %d is %dx%d, not %dx%d", identity.ImageNo, cam.imageWidth, cam.imageHeight, identity.Width, identity.Height)
+	}
+
+	return cam, world, nil
+}
+
+// Renders a range of frames of an animation, each to its own numbered file
+func runAnimation(options Options) error {
+	scene, ok := animations[options.animation]
+
+	if !ok {
+		return fmt.Errorf("there is no animation no. %d", options.animation)
+	}
+
+	first, last, err := parseFrameRange(options.frames)
+
+	if err != nil {
+		return err
+	}
+
+	if options.fps <= 0 || options.shutterAngle < 0 || options.shutterAngle > 360 {
+		return fmt.Errorf("-fps must be positive and -shutter-angle between 0 and 360")
+	}
+
+	if options.aov != "" || options.denoise || options.fireflies > 0 || options.robust > 0 || options.workers > 0 || options.crop != "" ||
+		options.trace != "" || options.progressive || options.checkpoint != "" || options.timeBudget > 0 || options.resume {
+		return fmt.Errorf("-aov, -denoise, -fireflies, -robust, -workers, -crop, -trace and progressive rendering can't be used with -animation")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+
+	for frame := first; frame <= last; frame++ {
+		// The shutter opens at the start of the frame and stays open for a fraction of the frame time
+		open := float64(frame) / options.fps
+		close := open + options.shutterAngle/360/options.fps
+
+		// The scene is built with the same seed for every frame, so that only the animated parameters change
+		SeedRandom(options.seed)
+
+		cam, cameraAnimation, world := scene(open)
+		cameraAnimation.Apply(&cam, (open+close)/2)
+		cam.SetShutter(open, close)
+
+		if options.samplesPerPixel > 0 {
+			cam.SetSamplesPerPixel(options.samplesPerPixel)
+		}
+
+		cam.SetSpectral(options.spectral)
+		cam.SetSampleClamp(options.clamp)
+
+		if options.apertureMask != "" {
+			if err := cam.SetApertureMask(options.apertureMask); err != nil {
+				return err
+			}
+		}
+
+		cam.Initialize()
+
+		filename := frameFilename(options.output, frame)
+
+		fmt.Fprintln(os.Stderr, "Rendering frame", frame, "of animation no.", options.animation, "on file", filename)
+
+		SeedRandom(passSeed(options.sampleSeed, frame))
+
+		film := NewFilm(cam.imageWidth, cam.imageHeight)
+		renderErr := cam.RenderContext(ctx, world, film, cam.samplesPerPixel, PrintProgress)
+
+		if renderErr != nil && renderErr != context.Canceled {
+			return renderErr
+		} else if renderErr != nil {
+			fmt.Fprintln(os.Stderr, "Render interrupted, writing the partial frame")
+		}
+
+		if err := WriteFilm(filename, film); err != nil {
+			return err
+		}
+
+		if renderErr != nil {
+			break
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Done in", time.Since(start))
+
+	return nil
+}
+
+// Parses a range of frames like 0-47, or a single frame like 12
+func parseFrameRange(frames string) (int, int, error) {
+	firstText, lastText, isRange := strings.Cut(frames, "-")
+
+	first, err := strconv.Atoi(firstText)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid frame range: %s", frames)
 	}
+
+	last := first
+
+	if isRange {
+		if last, err = strconv.Atoi(lastText); err != nil || last < first {
+			return 0, 0, fmt.Errorf("invalid frame range: %s", frames)
+		}
+	}
+
+	return first, last, nil
+}
+
+// Returns the file name of a frame: the output is used as a pattern if it contains a verb like %04d,
+// otherwise the frame number is added before the extension (out.ppm becomes out_0012.ppm)
+func frameFilename(output string, frame int) string {
+	if strings.Contains(output, "%") {
+		return fmt.Sprintf(output, frame)
+	}
+
+	ext := filepath.Ext(output)
+
+	return fmt.Sprintf("%s_%04d%s", strings.TrimSuffix(output, ext), frame, ext)
+}
+
+// Parses a list of n integers separated by commas, like 100,50,64,64
+func parseInts(text string, n int) ([]int, error) {
+	fields := strings.Split(text, ",")
+
+	if len(fields) != n {
+		return nil, fmt.Errorf("%s must be %d numbers separated by commas", text, n)
+	}
+
+	values := make([]int, n)
+
+	for i, field := range fields {
+		var err error
+
+		if values[i], err = strconv.Atoi(strings.TrimSpace(field)); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// Parses a list of AOV names separated by commas, all means all of them
+func parseAovs(text string) ([]string, error) {
+	if text == "all" {
+		return AovNames, nil
+	}
+
+	names := strings.Split(text, ",")
+
+	for _, name := range names {
+		if !slices.Contains(AovNames, name) {
+			return nil, fmt.Errorf("unknown AOV %s, the AOVs are %s", name, strings.Join(AovNames, ","))
+		}
+	}
+
+	return names, nil
+}
+
+// Returns true if the two lists have the same names, in any order
+func sameNames(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
+
+// Renders the tiles sent by a coordinator on stdin, it's started by the -workers option
+func runWorker(args []string) error {
+	return RunWorker(os.Stdin, os.Stdout, buildScene)
+}
+
+// Merges independent renders of the same scene, each pixel is the average of all the samples of all the renders
+func runMerge(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := flags.String("o", OutputFilename, "output file")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: merge [-o output] file1 file2 ...")
+		fmt.Fprintln(os.Stderr, "Input files can be .film renders or progressive render checkpoints")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no files to merge")
+	}
+
+	var merged *Film
+
+	for _, filename := range flags.Args() {
+		film, err := ReadFilm(filename)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Merging %s (%dx%d, %d samples per pixel)\n", filename, film.Width, film.Height, film.Samples())
+
+		if merged == nil {
+			merged = film
+		} else if err := merged.Merge(film); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Writing", *output, "with", merged.Samples(), "samples per pixel")
+
+	return WriteFilm(*output, merged)
 }
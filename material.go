@@ -55,7 +55,7 @@ func Refract(uv, n Vec3, etaiOverEtat float64) Vec3 {
 func (m MetalMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
 	reflected := Reflect(ray.Direction().UnitVector(), rec.Normal)
 
-	*scattered = NewRay(rec.P, reflected.Add(NewRandomUnitVec3().Mul(m.fuzz)), ray.Time())
+	*scattered = ray.Bounce(rec.P, reflected.Add(NewRandomUnitVec3().Mul(m.fuzz)))
 	*attenuation = m.albedo
 
 	// We should just return true here, but because of the fuzziness it may happen that a ray is scattered below the surface.
@@ -96,10 +96,10 @@ func (m DielectricMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color,
 
 	if cannotRefract {
 		reflected := Reflect(unitDirection, rec.Normal)
-		*scattered = NewRay(rec.P, reflected, ray.Time())
+		*scattered = ray.Bounce(rec.P, reflected)
 	} else {
 		refracted := Refract(unitDirection, rec.Normal, refractionRatio)
-		*scattered = NewRay(rec.P, refracted, ray.Time())
+		*scattered = ray.Bounce(rec.P, refracted)
 	}
 
 	*attenuation = Color{1, 1, 1}
@@ -120,7 +120,7 @@ func (m TextureLambertianMaterial) Scatter(ray Ray, rec *HitRecord, attenuation
 		scatterDirection = rec.Normal
 	}
 
-	*scattered = NewRay(rec.P, scatterDirection, ray.Time())
+	*scattered = ray.Bounce(rec.P, scatterDirection)
 	*attenuation = m.texture.Value(rec.U, rec.V, rec.P)
 
 	return true
@@ -137,7 +137,7 @@ func NewIsotropicMaterial(a Texture) IsotropicMaterial {
 }
 
 func (m IsotropicMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
-	*scattered = NewRay(rec.P, NewRandomUnitVec3(), ray.Time())
+	*scattered = ray.Bounce(rec.P, NewRandomUnitVec3())
 	*attenuation = m.albedo.Value(rec.U, rec.V, rec.P)
 	return true
 }
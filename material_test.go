@@ -0,0 +1,376 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns the direction towards the viewer at the given angle from the normal of the surface of scatterAt
+func viewDirection(degrees float64) Vec3 {
+	theta := DegreesToRadians(degrees)
+	return NewVec3(math.Sin(theta), 0, math.Cos(theta))
+}
+
+// Scatters a ray that comes from the direction wo (towards the viewer) and hits a surface at the origin, whose normal
+// is the z axis. Returns the weight and the direction of the scattered ray, the weight is 0 if the ray is absorbed.
+func scatterAt(mat Material, wo Vec3) (Color, Vec3) {
+	ray := NewRay(Point3(wo), wo.Negate(), 0)
+	rec := HitRecord{P: NewPoint3(0, 0, 0), Normal: NewVec3(0, 0, 1), T: 1, FrontFace: true, Mat: mat, U: 0.5, V: 0.5}
+
+	var attenuation Color
+	var scattered Ray
+
+	if !scatter(mat, ray, &rec, &attenuation, &scattered) {
+		return Color{}, Vec3{}
+	}
+
+	return attenuation, scattered.Direction().UnitVector()
+}
+
+// White furnace test: returns the fraction of the light coming from the direction wo that the material scatters (its
+// albedo), the average weight of the scattered rays
+func albedo(mat Material, wo Vec3, samples int) Color {
+	sum := Color{}
+
+	for i := 0; i < samples; i++ {
+		weight, _ := scatterAt(mat, wo)
+		sum = sum.Add(weight)
+	}
+
+	return sum.Div(float64(samples))
+}
+
+// Checks that a white material scatters between min and max of the light it receives, at every angle
+func checkFurnace(t *testing.T, name string, mat Material, min, max float64) {
+	t.Helper()
+
+	for _, degrees := range []float64{0, 30, 60, 80} {
+		a := albedo(mat, viewDirection(degrees), 20000)
+
+		for _, c := range []float64{a.X, a.Y, a.Z} {
+			if c < min || c > max {
+				t.Errorf("%s scatters %.3f of the light at %g degrees, want between %g and %g", name, c, degrees, min, max)
+				break
+			}
+		}
+	}
+}
+
+// Returns a random direction above the surface, with a cosine distribution around the normal
+func randomCosineDirection() Vec3 {
+	phi := 2 * math.Pi * RandomDouble()
+	r2 := RandomDouble()
+
+	return NewVec3(math.Cos(phi)*math.Sqrt(r2), math.Sin(phi)*math.Sqrt(r2), math.Sqrt(1-r2))
+}
+
+// Returns the band of a direction above the surface, the bands split the cosines of the angles from the normal in
+// equal parts, or -1 if the direction is below the surface
+func cosineBand(w Vec3, bands int) int {
+	if w.Z <= 0 {
+		return -1
+	}
+
+	return int(math.Min(w.Z, 0.999999) * float64(bands))
+}
+
+// Reciprocity test: the light reflected from each band of directions to each other band, for light coming from all the
+// directions with a cosine distribution. It's the integral of the BRDF times both cosines over the two bands, so it
+// doesn't change if the directions are swapped: the matrix of a reciprocal material is symmetric.
+func reflectionMatrix(mat Material, bands, samples int) [][]float64 {
+	matrix := make([][]float64, bands)
+	for i := range matrix {
+		matrix[i] = make([]float64, bands)
+	}
+
+	for i := 0; i < samples; i++ {
+		wo := randomCosineDirection()
+		weight, wi := scatterAt(mat, wo)
+
+		if b := cosineBand(wi, bands); b >= 0 {
+			matrix[cosineBand(wo, bands)][b] += (weight.X + weight.Y + weight.Z) / 3 / float64(samples)
+		}
+	}
+
+	return matrix
+}
+
+// Checks that a material reflects as much light from each band of directions to each other band as in the opposite direction
+func checkReciprocity(t *testing.T, name string, mat Material) {
+	t.Helper()
+
+	const bands = 3
+	matrix := reflectionMatrix(mat, bands, 300000)
+
+	for i := 0; i < bands; i++ {
+		for j := i + 1; j < bands; j++ {
+			if math.Abs(matrix[i][j]-matrix[j][i]) > 0.005 {
+				t.Errorf("%s reflects %.4f from band %d to band %d, but %.4f from band %d to band %d", name, matrix[i][j], i, j, matrix[j][i], j, i)
+			}
+		}
+	}
+}
+
+// A conductor that reflects all the light
+var whiteConductor = ConductorIOR{Eta: NewColor(1, 1, 1), K: NewColor(1e4, 1e4, 1e4)}
+
+func TestConductorFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	// A smooth surface loses no light, a rough one loses the light that bounces between the microfacets
+	checkFurnace(t, "a smooth conductor", NewConductorMaterial(whiteConductor, 0.1), 0.99, 1)
+	checkFurnace(t, "a rough conductor", NewConductorMaterial(whiteConductor, 0.5), 0.84, 1)
+	checkFurnace(t, "an anisotropic conductor", NewAnisotropicConductorMaterial(whiteConductor, 0.5, 0.8), 0.75, 1)
+}
+
+func TestConductorReciprocity(t *testing.T) {
+	SeedRandom(1)
+
+	checkReciprocity(t, "a conductor", NewConductorMaterial(Gold, 0.5))
+}
+
+func TestAnisotropicTangent(t *testing.T) {
+	SeedRandom(1)
+
+	// At normal incidence the reflections of brushed metal spread more along the tangent than across it
+	spread := func(tangent Vec3) (float64, float64) {
+		mat := NewAnisotropicConductorMaterial(whiteConductor, 0.5, 0.9)
+		ray := NewRay(NewPoint3(0, 0, 1), NewVec3(0, 0, -1), 0)
+
+		var x, y float64
+		for i := 0; i < 10000; i++ {
+			rec := HitRecord{P: NewPoint3(0, 0, 0), Normal: NewVec3(0, 0, 1), Tangent: tangent, T: 1, FrontFace: true, Mat: mat}
+
+			var attenuation Color
+			var scattered Ray
+			if mat.Scatter(ray, &rec, &attenuation, &scattered) {
+				d := scattered.Direction().UnitVector()
+				x, y = x+math.Abs(d.X), y+math.Abs(d.Y)
+			}
+		}
+
+		return x, y
+	}
+
+	if x, y := spread(NewVec3(3, 0, 0)); x < 1.5*y {
+		t.Errorf("with the tangent along X the reflections spread %.0f along X and %.0f along Y", x, y)
+	}
+
+	if x, y := spread(NewVec3(0, 1, 0.5)); y < 1.5*x {
+		t.Errorf("with the tangent along Y the reflections spread %.0f along X and %.0f along Y", x, y)
+	}
+
+	// The tangent of a sphere doesn't jump across the equator, where the basis built from the normal alone flips
+	sphere := NewSphere(NewPoint3(0, 0, 0), 1, nil)
+	var bases []Onb
+
+	for _, z := range []float64{1e-6, -1e-6} {
+		n := NewVec3(1, 0.5, z).UnitVector()
+
+		rec := HitRecord{}
+		if !sphere.Hit(NewRay(Point3(n.Mul(2)), n.Negate(), 0), 0.001, math.Inf(+1), &rec) {
+			t.Fatalf("the ray towards %v misses the sphere", n)
+		}
+
+		bases = append(bases, NewTangentOnb(rec.Normal, rec.Tangent))
+	}
+
+	if !nearVec3(bases[0].U, bases[1].U, 1e-3) || !nearVec3(bases[0].V, bases[1].V, 1e-3) {
+		t.Errorf("the tangents above and below the equator are %v and %v", bases[0].U, bases[1].U)
+	}
+}
+
+func TestRoughDielectricFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	// All the light is reflected or transmitted, only the light blocked by the microfacets is lost
+	checkFurnace(t, "smooth glass", NewRoughDielectricMaterial(1.5, 0), 0.999, 1)
+	checkFurnace(t, "frosted glass", NewRoughDielectricMaterial(1.5, 0.5), 0.8, 1)
+}
+
+func TestRoughDielectricReciprocity(t *testing.T) {
+	SeedRandom(1)
+
+	// The light that is reflected, the transmitted light is reciprocal only up to the ratio of the indices of refraction
+	checkReciprocity(t, "frosted glass", NewRoughDielectricMaterial(1.5, 0.5))
+}
+
+func TestRoughDielectricAbsorption(t *testing.T) {
+	SeedRandom(1)
+
+	tint := NewColor(0.2, 0.5, 0.9)
+	mat := NewTintedRoughDielectricMaterial(1.5, NewSolidColorTexture(Color{}), tint, 2)
+
+	// A ray that leaves the material after traveling 2 units inside it keeps the tint, whether it exits or it's reflected back
+	ray := NewRay(NewPoint3(0, 0, 2), NewVec3(0, 0, -1), 0)
+	rec := HitRecord{P: NewPoint3(0, 0, 0), Normal: NewVec3(0, 0, 1), T: 2, FrontFace: false, Mat: mat}
+
+	var attenuation Color
+	var scattered Ray
+
+	for i := 0; i < 100; i++ {
+		if !mat.Scatter(ray, &rec, &attenuation, &scattered) || !nearVec3(attenuation, tint, 1e-9) {
+			t.Fatalf("the light that traveled through the material is %v, want %v", attenuation, tint)
+		}
+	}
+}
+
+func TestDispersiveFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	// The rays pick a random wavelength, on average white light stays white
+	checkFurnace(t, "dispersive glass", NewDispersiveMaterial(FlintGlass, 0), 0.95, 1.05)
+}
+
+// Returns the parameters of a white principled material, with the given metallic and roughness
+func whitePrincipledParams(metallic, roughness float64) PrincipledParams {
+	params := NewPrincipledParams()
+	params.BaseColor = NewSolidColorTexture(NewColor(1, 1, 1))
+	params.Metallic = NewSolidColorTexture(NewColor(metallic, metallic, metallic))
+	params.Roughness = NewSolidColorTexture(NewColor(roughness, roughness, roughness))
+
+	return params
+}
+
+func TestPrincipledFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	// The metal reflects the light like a white conductor (Schlick's Fresnel is 1 for a white base color)
+	checkFurnace(t, "a principled metal", NewPrincipledMaterial(whitePrincipledParams(1, 0.5)), 0.84, 1)
+
+	// The plastic diffuses all the light that it doesn't reflect
+	checkFurnace(t, "a principled plastic", NewPrincipledMaterial(whitePrincipledParams(0, 0.3)), 0.9, 1)
+
+	// The sheen takes its light from the base, so it can't add any
+	cloth := whitePrincipledParams(0, 0.5)
+	cloth.Sheen, cloth.SheenColor = 1, NewColor(1, 1, 1)
+	checkFurnace(t, "a principled material with sheen", NewPrincipledMaterial(cloth), 0.9, 1)
+
+	coated := whitePrincipledParams(0, 0.5)
+	coated.Clearcoat, coated.ClearcoatRoughness = 1, 0.1
+	checkFurnace(t, "a principled material with a clear coat", NewPrincipledMaterial(coated), 0.9, 1)
+
+	glass := whitePrincipledParams(0, 0)
+	glass.Transmission = NewSolidColorTexture(NewColor(1, 1, 1))
+	checkFurnace(t, "principled glass", NewPrincipledMaterial(glass), 0.999, 1)
+}
+
+func TestPrincipledSheen(t *testing.T) {
+	SeedRandom(1)
+
+	// A dark base under a white sheen reflects more light at grazing angles than at normal incidence
+	params := NewPrincipledParams()
+	params.BaseColor, params.IOR = NewSolidColorTexture(NewColor(0.1, 0.1, 0.1)), 1
+	params.Sheen, params.SheenColor = 1, NewColor(1, 1, 1)
+	mat := NewPrincipledMaterial(params)
+
+	if normal, grazing := albedo(mat, viewDirection(0), 20000), albedo(mat, viewDirection(80), 20000); grazing.X <= normal.X {
+		t.Errorf("the sheen scatters %.3f of the light at 80 degrees and %.3f at 0 degrees, want more at grazing angles", grazing.X, normal.X)
+	}
+}
+
+func TestPrincipledReciprocity(t *testing.T) {
+	SeedRandom(1)
+
+	checkReciprocity(t, "a principled metal", NewPrincipledMaterial(whitePrincipledParams(1, 0.5)))
+
+	// Without reflections (an index of refraction of 1) the diffuse base and the sheen are left
+	cloth := NewPrincipledParams()
+	cloth.IOR, cloth.Sheen, cloth.SheenColor = 1, 1, NewColor(1, 0.5, 0.2)
+	checkReciprocity(t, "a principled material with sheen", NewPrincipledMaterial(cloth))
+
+	// The light scattered by the base goes through the clear coat both ways
+	coated := NewPrincipledParams()
+	coated.IOR, coated.Roughness, coated.Clearcoat, coated.ClearcoatRoughness = 1, NewSolidColorTexture(NewColor(0.3, 0.3, 0.3)), 1, 0.3
+	checkReciprocity(t, "a principled material with a clear coat", NewPrincipledMaterial(coated))
+
+	coated.Clearcoat = 0.5
+	checkReciprocity(t, "a principled material with half a clear coat", NewPrincipledMaterial(coated))
+}
+
+func TestPrincipledClearcoatClamp(t *testing.T) {
+	// MTL files can hold any clear coat thickness
+	for _, test := range []struct{ pc, want float64 }{{-1, 0}, {0.5, 0.5}, {3, 1}} {
+		mtl := NewMtlParams()
+		mtl.Pc = test.pc
+
+		if got := NewMtlMaterial(mtl).params.Clearcoat; got != test.want {
+			t.Errorf("a clear coat of %v has strength %v, want %v", test.pc, got, test.want)
+		}
+	}
+}
+
+func TestGltfBaseColorTexture(t *testing.T) {
+	// The texture holds sRGB colors, the material uses linear colors
+	gltf := NewGltfParams()
+	gltf.BaseColorTexture = NewSolidColorTexture(NewColor(0, 0.5, 1))
+	gltf.BaseColorFactor = NewColor(1, 1, 0.5)
+
+	got := NewGltfMaterial(gltf).params.BaseColor.Value(0, 0, Point3{})
+	if want := NewColor(0, 0.214041, 0.5); !nearVec3(got, want, 1e-6) {
+		t.Errorf("the base color is %v, want %v", got, want)
+	}
+}
+
+func TestMixFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	white := NewLambertianMaterial(NewColor(1, 1, 1))
+	checkFurnace(t, "a mix", NewMixMaterial(white, NewConductorMaterial(whiteConductor, 0.1), 0.5), 0.99, 1)
+
+	mask := NewBicolorCheckerTexture(1, NewColor(0.2, 0.2, 0.2), NewColor(0.8, 0.8, 0.8))
+	checkFurnace(t, "a textured mix", NewTextureMixMaterial(white, NewRoughDielectricMaterial(1.5, 0), mask), 0.99, 1)
+
+	// The mask picks the material in proportion to the amount
+	mix := NewMixMaterial(white, NewLambertianMaterial(Color{}), 0.25)
+	if a := albedo(mix, viewDirection(0), 20000); math.Abs(a.X-0.75) > 0.02 {
+		t.Errorf("a mix of 3/4 white and 1/4 black scatters %.3f of the light, want 0.75", a.X)
+	}
+}
+
+func TestMixReciprocity(t *testing.T) {
+	SeedRandom(1)
+
+	checkReciprocity(t, "a mix", NewMixMaterial(NewLambertianMaterial(NewColor(0.8, 0.5, 0.2)), NewConductorMaterial(Gold, 0.4), 0.5))
+}
+
+func TestCoatedFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	// The coat reflects some light and the base scatters all the rest, a rough coat loses the light blocked by its microfacets
+	white := NewLambertianMaterial(NewColor(1, 1, 1))
+	checkFurnace(t, "a coat", NewCoatedMaterial(white, 1.5, 0), 0.98, 1.02)
+	checkFurnace(t, "a rough coat", NewCoatedMaterial(white, 1.5, 0.5), 0.9, 1.02)
+
+	roughness := NewBicolorCheckerTexture(1, Color{}, NewColor(0.5, 0.5, 0.5))
+	checkFurnace(t, "a textured coat", NewTextureCoatedMaterial(white, 1.5, roughness), 0.9, 1.02)
+}
+
+func TestCoatedReciprocity(t *testing.T) {
+	SeedRandom(1)
+
+	checkReciprocity(t, "a coat", NewCoatedMaterial(NewLambertianMaterial(NewColor(0.2, 0.3, 0.8)), 1.5, 0.3))
+}
+
+func TestOrenNayarFurnace(t *testing.T) {
+	SeedRandom(1)
+
+	// The light that bounces between the facets is not lost, at any roughness
+	white := NewColor(1, 1, 1)
+	checkFurnace(t, "a rough matte material", NewOrenNayarMaterial(white, 0.5), 0.98, 1.02)
+	checkFurnace(t, "the roughest matte material", NewOrenNayarMaterial(white, 1), 0.98, 1.02)
+
+	// Without roughness it's a Lambertian material
+	c := NewColor(0.2, 0.5, 0.8)
+	for i := 0; i < 100; i++ {
+		if weight, _ := scatterAt(NewOrenNayarMaterial(c, 0), viewDirection(60)); !nearVec3(weight, c, 1e-9) {
+			t.Fatalf("a smooth matte material scatters with weight %v, want %v", weight, c)
+		}
+	}
+}
+
+func TestOrenNayarReciprocity(t *testing.T) {
+	SeedRandom(1)
+
+	checkReciprocity(t, "a rough matte material", NewOrenNayarMaterial(NewColor(0.8, 0.5, 0.2), 1))
+}
@@ -0,0 +1,163 @@
+package main
+
+import "math"
+
+// A 4x4 matrix used for affine transforms, it's applied to column vectors so that m.Mul(n) is the transform that applies n first and then m.
+// Transforms are easier to read when composed in order with Then(), e.g. NewScaling(s).Then(NewRotationY(a)).Then(NewTranslation(t))
+type Mat4 [4][4]float64
+
+func IdentityMat4() Mat4 {
+	return Mat4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1}}
+}
+
+func NewTranslation(offset Vec3) Mat4 {
+	return Mat4{
+		{1, 0, 0, offset.X},
+		{0, 1, 0, offset.Y},
+		{0, 0, 1, offset.Z},
+		{0, 0, 0, 1}}
+}
+
+// Non-uniform scaling, every component of the vector is the scale along its axis
+func NewScaling(scale Vec3) Mat4 {
+	return Mat4{
+		{scale.X, 0, 0, 0},
+		{0, scale.Y, 0, 0},
+		{0, 0, scale.Z, 0},
+		{0, 0, 0, 1}}
+}
+
+// Rotation around an arbitrary axis through the origin, counterclockwise when looking from the tip of the axis (Rodrigues' formula)
+func NewRotation(axis Vec3, angleInDegrees float64) Mat4 {
+	a := axis.UnitVector()
+	theta := DegreesToRadians(angleInDegrees)
+	sin, cos := math.Sin(theta), math.Cos(theta)
+	t := 1 - cos
+
+	return Mat4{
+		{t*a.X*a.X + cos, t*a.X*a.Y - sin*a.Z, t*a.X*a.Z + sin*a.Y, 0},
+		{t*a.X*a.Y + sin*a.Z, t*a.Y*a.Y + cos, t*a.Y*a.Z - sin*a.X, 0},
+		{t*a.X*a.Z - sin*a.Y, t*a.Y*a.Z + sin*a.X, t*a.Z*a.Z + cos, 0},
+		{0, 0, 0, 1}}
+}
+
+func NewRotationX(angleInDegrees float64) Mat4 {
+	return NewRotation(NewVec3(1, 0, 0), angleInDegrees)
+}
+
+func NewRotationY(angleInDegrees float64) Mat4 {
+	return NewRotation(NewVec3(0, 1, 0), angleInDegrees)
+}
+
+func NewRotationZ(angleInDegrees float64) Mat4 {
+	return NewRotation(NewVec3(0, 0, 1), angleInDegrees)
+}
+
+// Shear along the X axis: x' = x + xy*y + xz*z
+func NewShearX(xy, xz float64) Mat4 {
+	m := IdentityMat4()
+	m[0][1], m[0][2] = xy, xz
+	return m
+}
+
+// Places an object at "from" with its Z axis pointing at "to", and its Y axis as close as possible to "up"
+func NewLookAt(from, to Point3, up Vec3) Mat4 {
+	w := to.Sub(from).UnitVector()
+	u := up.Cross(w).UnitVector()
+	v := w.Cross(u)
+
+	return Mat4{
+		{u.X, v.X, w.X, from.X},
+		{u.Y, v.Y, w.Y, from.Y},
+		{u.Z, v.Z, w.Z, from.Z},
+		{0, 0, 0, 1}}
+}
+
+func (m Mat4) Mul(n Mat4) Mat4 {
+	r := Mat4{}
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				r[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+
+	return r
+}
+
+// Returns the transform that applies m first and then n
+func (m Mat4) Then(n Mat4) Mat4 {
+	return n.Mul(m)
+}
+
+func (m Mat4) Transpose() Mat4 {
+	r := Mat4{}
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			r[i][j] = m[j][i]
+		}
+	}
+
+	return r
+}
+
+// Inverse of an affine transform: the inverse of the upper 3x3 part (computed with cofactors) followed by the opposite translation.
+// Returns false if the transform is singular (e.g. a scale is 0).
+func (m Mat4) Inverse() (Mat4, bool) {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+
+	if det == 0 {
+		return Mat4{}, false
+	}
+
+	invDet := 1 / det
+
+	r := IdentityMat4()
+	r[0][0] = (m[1][1]*m[2][2] - m[1][2]*m[2][1]) * invDet
+	r[0][1] = (m[0][2]*m[2][1] - m[0][1]*m[2][2]) * invDet
+	r[0][2] = (m[0][1]*m[1][2] - m[0][2]*m[1][1]) * invDet
+	r[1][0] = (m[1][2]*m[2][0] - m[1][0]*m[2][2]) * invDet
+	r[1][1] = (m[0][0]*m[2][2] - m[0][2]*m[2][0]) * invDet
+	r[1][2] = (m[0][2]*m[1][0] - m[0][0]*m[1][2]) * invDet
+	r[2][0] = (m[1][0]*m[2][1] - m[1][1]*m[2][0]) * invDet
+	r[2][1] = (m[0][1]*m[2][0] - m[0][0]*m[2][1]) * invDet
+	r[2][2] = (m[0][0]*m[1][1] - m[0][1]*m[1][0]) * invDet
+
+	t := r.TransformVector(NewVec3(m[0][3], m[1][3], m[2][3]))
+	r[0][3], r[1][3], r[2][3] = -t.X, -t.Y, -t.Z
+
+	return r, true
+}
+
+func (m Mat4) TransformPoint(p Point3) Point3 {
+	return NewPoint3(
+		m[0][0]*p.X+m[0][1]*p.Y+m[0][2]*p.Z+m[0][3],
+		m[1][0]*p.X+m[1][1]*p.Y+m[1][2]*p.Z+m[1][3],
+		m[2][0]*p.X+m[2][1]*p.Y+m[2][2]*p.Z+m[2][3])
+}
+
+// Vectors are not affected by translations
+func (m Mat4) TransformVector(v Vec3) Vec3 {
+	return NewVec3(
+		m[0][0]*v.X+m[0][1]*v.Y+m[0][2]*v.Z,
+		m[1][0]*v.X+m[1][1]*v.Y+m[1][2]*v.Z,
+		m[2][0]*v.X+m[2][1]*v.Y+m[2][2]*v.Z)
+}
+
+// Normals must be transformed by the inverse transpose to stay perpendicular to the surface, so m must be
+// the inverse of the transform (which is usually at hand) and this function applies its transpose. The result is not normalized.
+func (m Mat4) TransformNormal(n Vec3) Vec3 {
+	return NewVec3(
+		m[0][0]*n.X+m[1][0]*n.Y+m[2][0]*n.Z,
+		m[0][1]*n.X+m[1][1]*n.Y+m[2][1]*n.Z,
+		m[0][2]*n.X+m[1][2]*n.Y+m[2][2]*n.Z)
+}
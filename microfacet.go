@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Microfacet surfaces are made of tiny perfect mirrors (microfacets), whose normals are spread around the surface normal
+// according to the GGX (Trowbridge-Reitz) distribution: the rougher the surface, the wider the spread.
+// Computations happen in a local frame where the surface normal is the Z axis.
+
+// An orthonormal basis built around a normal, the normal is the Z axis
+type Onb struct {
+	U, V, W Vec3
+}
+
+// Builds a basis around a unit normal, without branches that make the tangents jump (Duff et al., 2017)
+func NewOnb(n Vec3) Onb {
+	sign := math.Copysign(1, n.Z)
+	a := -1 / (sign + n.Z)
+	b := n.X * n.Y * a
+
+	return Onb{
+		U: NewVec3(1+sign*n.X*n.X*a, sign*b, -sign*n.X),
+		V: NewVec3(b, sign+n.Y*n.Y*a, -n.Y),
+		W: n}
+}
+
+// Builds a basis around a unit normal whose first axis follows the tangent (e.g. the direction of the U coordinate of the
+// surface), the tangent needn't be unit or perpendicular to the normal. If it's zero or along the normal any basis is built.
+func NewTangentOnb(n, tangent Vec3) Onb {
+	t := tangent.Sub(n.Mul(n.Dot(tangent)))
+
+	if l := t.Length(); l > 1e-9*tangent.Length() {
+		u := t.Div(l)
+		return Onb{U: u, V: n.Cross(u), W: n}
+	}
+
+	return NewOnb(n)
+}
+
+// From world coordinates to local coordinates
+func (onb Onb) ToLocal(v Vec3) Vec3 {
+	return NewVec3(v.Dot(onb.U), v.Dot(onb.V), v.Dot(onb.W))
+}
+
+// From local coordinates to world coordinates
+func (onb Onb) ToWorld(v Vec3) Vec3 {
+	return onb.U.Mul(v.X).Add(onb.V.Mul(v.Y)).Add(onb.W.Mul(v.Z))
+}
+
+// The roughness of a GGX distribution along the two tangent directions
+type Ggx struct {
+	AlphaX, AlphaY float64
+}
+
+// Converts a perceptual roughness in [0,1] (0 is a mirror) to a GGX distribution, anisotropy in [-1,1] stretches the
+// highlights along one of the tangent directions
+func NewGgx(roughness, anisotropy float64) Ggx {
+	alpha := math.Max(roughness*roughness, 1e-4) // Very small values are numerically unstable
+	aspect := math.Sqrt(1 - 0.9*math.Abs(anisotropy))
+
+	if anisotropy >= 0 {
+		return Ggx{AlphaX: math.Max(alpha/aspect, 1e-4), AlphaY: math.Max(alpha*aspect, 1e-4)}
+	}
+
+	return Ggx{AlphaX: math.Max(alpha*aspect, 1e-4), AlphaY: math.Max(alpha/aspect, 1e-4)}
+}
+
+// Smith's auxiliary function, it tells how much a direction is shadowed by the microfacets
+func (ggx Ggx) Lambda(w Vec3) float64 {
+	if w.Z == 0 {
+		return math.Inf(+1)
+	}
+
+	a2 := (ggx.AlphaX*ggx.AlphaX*w.X*w.X + ggx.AlphaY*ggx.AlphaY*w.Y*w.Y) / (w.Z * w.Z)
+
+	return (math.Sqrt(1+a2) - 1) / 2
+}
+
+// Fraction of the microfacets seen from a direction that are not hidden by other microfacets
+func (ggx Ggx) G1(w Vec3) float64 {
+	return 1 / (1 + ggx.Lambda(w))
+}
+
+// Fraction of the microfacets seen from both directions
+func (ggx Ggx) G2(wo, wi Vec3) float64 {
+	return 1 / (1 + ggx.Lambda(wo) + ggx.Lambda(wi))
+}
+
+// Returns a random microfacet normal among the ones visible from the direction wo, which must be above the surface
+// (Heitz, "Sampling the GGX Distribution of Visible Normals", 2018)
+func (ggx Ggx) SampleVisibleNormal(wo Vec3) Vec3 {
+	// Stretch the view direction, so that the distribution becomes a hemisphere
+	vh := NewVec3(ggx.AlphaX*wo.X, ggx.AlphaY*wo.Y, wo.Z).UnitVector()
+
+	lensq := vh.X*vh.X + vh.Y*vh.Y
+	t1 := NewVec3(1, 0, 0)
+	if lensq > 0 {
+		t1 = NewVec3(-vh.Y, vh.X, 0).Div(math.Sqrt(lensq))
+	}
+	t2 := vh.Cross(t1)
+
+	// Sample the projected area of the hemisphere
+	r := math.Sqrt(RandomDouble())
+	phi := 2 * math.Pi * RandomDouble()
+	p1 := r * math.Cos(phi)
+	p2 := r * math.Sin(phi)
+	s := (1 + vh.Z) / 2
+	p2 = (1-s)*math.Sqrt(1-p1*p1) + s*p2
+
+	nh := t1.Mul(p1).Add(t2.Mul(p2)).Add(vh.Mul(math.Sqrt(math.Max(0, 1-p1*p1-p2*p2))))
+
+	// Unstretch the normal
+	return NewVec3(ggx.AlphaX*nh.X, ggx.AlphaY*nh.Y, math.Max(0, nh.Z)).UnitVector()
+}
+
+// Reflectance of a conductor with complex index of refraction eta + ik, for light that hits it at an angle with the
+// given cosine, for unpolarized light (average of the two polarizations)
+func FresnelConductor(cosTheta, eta, k float64) float64 {
+	cosTheta = math.Max(0, math.Min(1, cosTheta))
+
+	n := complex(eta, k)
+	c := complex(cosTheta, 0)
+	sin2 := complex(1-cosTheta*cosTheta, 0)
+	cosT := cmplx.Sqrt(1 - sin2/(n*n)) // Snell's law, the refracted angle is complex too
+
+	rParallel := (n*c - cosT) / (n*c + cosT)
+	rPerpendicular := (c - n*cosT) / (c + n*cosT)
+
+	abs2 := func(z complex128) float64 { return real(z)*real(z) + imag(z)*imag(z) }
+
+	return (abs2(rParallel) + abs2(rPerpendicular)) / 2
+}
+
+// Reflectance of a dielectric for light that hits it at an angle with the given cosine, coming from a medium
+// with a refraction index that is 1/eta times the index of the other side, for unpolarized light.
+// Returns 1 if there is total internal reflection.
+func FresnelDielectric(cosTheta, eta float64) float64 {
+	cosTheta = math.Max(0, math.Min(1, cosTheta))
+
+	sin2T := (1 - cosTheta*cosTheta) / (eta * eta)
+	if sin2T >= 1 {
+		return 1
+	}
+	cosT := math.Sqrt(1 - sin2T)
+
+	rParallel := (eta*cosTheta - cosT) / (eta*cosTheta + cosT)
+	rPerpendicular := (cosTheta - eta*cosT) / (cosTheta + eta*cosT)
+
+	return (rParallel*rParallel + rPerpendicular*rPerpendicular) / 2
+}
@@ -0,0 +1,53 @@
+package main
+
+import "math"
+
+// A mix of two materials, e.g. patches of rust on a metal. The mask says how much of the second material there is
+// at every point, from the first component of the texture: 0 is only the first material, 1 only the second.
+// Every ray is scattered by one of the two materials, picked at random in proportion to the mask, while the emitted
+// light is blended.
+type MixMaterial struct {
+	first, second Material
+	mask          Texture
+}
+
+func NewMixMaterial(first, second Material, amount float64) MixMaterial {
+	return NewTextureMixMaterial(first, second, NewSolidColorTexture(NewColor(amount, amount, amount)))
+}
+
+func NewTextureMixMaterial(first, second Material, mask Texture) MixMaterial {
+	return MixMaterial{first: first, second: second, mask: mask}
+}
+
+func (m MixMaterial) amount(u, v float64, p Point3) float64 {
+	return math.Max(0, math.Min(1, m.mask.Value(u, v, p).X))
+}
+
+// Picks one of the two materials at random
+func (m MixMaterial) pick(rec *HitRecord) Material {
+	if RandomDouble() < m.amount(rec.U, rec.V, rec.P) {
+		return m.second
+	}
+
+	return m.first
+}
+
+func (m MixMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	return m.pick(rec).Scatter(ray, rec, attenuation, scattered)
+}
+
+func (m MixMaterial) ScatterSpectral(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	return scatter(m.pick(rec), ray, rec, attenuation, scattered)
+}
+
+func (m MixMaterial) Emitted(u, v float64, p Point3) Color {
+	t := m.amount(u, v, p)
+
+	return m.first.Emitted(u, v, p).Mul(1 - t).Add(m.second.Emitted(u, v, p).Mul(t))
+}
+
+func (m MixMaterial) EmittedSpectral(u, v float64, p Point3, wavelengths Vec3) Color {
+	t := m.amount(u, v, p)
+
+	return emittedSpectral(m.first, u, v, p, wavelengths).Mul(1 - t).Add(emittedSpectral(m.second, u, v, p, wavelengths).Mul(t))
+}
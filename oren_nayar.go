@@ -0,0 +1,87 @@
+package main
+
+import "math"
+
+// An Oren-Nayar material is a rough matte surface (clay, concrete, the moon), made of tiny Lambertian facets: compared
+// to a Lambertian material it looks flatter, as its edges are brighter and it reflects more light back towards the light.
+// It's the energy-preserving model of Portsmouth et al., 2024 (EON): the improved Oren-Nayar model of Fujii, plus the
+// light that bounces between the facets, so a white surface reflects all the light at any roughness.
+// Roughness is in [0,1], 0 is a Lambertian material and 1 the roughest surface.
+// It can replace a Lambertian material anywhere.
+type OrenNayarMaterial struct {
+	BlackEmitter
+	texture   Texture
+	roughness float64
+	af        float64 // Scale of the single scattering, which keeps the albedo of a white surface below 1
+	avgEF     float64 // Albedo of the single scattering of a white surface, averaged over all the directions
+}
+
+// Constants of the Fujii model
+const (
+	fujiiC1 = 0.5 - 2/(3*math.Pi)
+	fujiiC2 = 2.0/3 - 28/(15*math.Pi)
+)
+
+func NewTextureOrenNayarMaterial(texture Texture, roughness float64) OrenNayarMaterial {
+	r := math.Max(0, math.Min(1, roughness))
+	af := 1 / (1 + fujiiC1*r)
+
+	return OrenNayarMaterial{texture: texture, roughness: r, af: af, avgEF: af * (1 + fujiiC2*r)}
+}
+
+func NewOrenNayarMaterial(a Color, roughness float64) OrenNayarMaterial {
+	return NewTextureOrenNayarMaterial(NewSolidColorTexture(a), roughness)
+}
+
+// Returns the albedo of the single scattering of a white surface, for light that comes from a direction with the given
+// cosine from the normal
+func (m OrenNayarMaterial) singleAlbedo(mu float64) float64 {
+	mu = math.Max(1e-6, math.Min(1, mu))
+	sinTheta := math.Sqrt(1 - mu*mu)
+	g := sinTheta*(math.Acos(mu)-sinTheta*mu) + 2.0/3*((sinTheta/mu)*(1-sinTheta*sinTheta*sinTheta)-sinTheta)
+
+	return m.af + m.roughness*m.af/math.Pi*g
+}
+
+func (m OrenNayarMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	// The directions are sampled as for a Lambertian material
+	scatterDirection := rec.Normal.Add(NewRandomUnitVec3())
+
+	if scatterDirection.NearZero() {
+		scatterDirection = rec.Normal
+	}
+
+	onb := NewOnb(rec.Normal)
+	wo := onb.ToLocal(ray.Direction().UnitVector().Negate())
+	wi := onb.ToLocal(scatterDirection.UnitVector())
+	muO, muI := math.Max(0, wo.Z), math.Max(0, wi.Z)
+
+	// The single scattering of the Fujii model, s over t depends on the angle between the two directions
+	sOverT := wi.Dot(wo) - muI*muO
+	if sOverT > 0 {
+		sOverT /= math.Max(muI, muO)
+	}
+
+	rho := m.texture.Value(rec.U, rec.V, rec.P)
+	single := rho.Mul(m.af * (1 + m.roughness*sOverT))
+
+	// The multiple scattering adds the light lost by the single scattering, its color is more saturated because the
+	// light bounces more times
+	const epsilon = 1e-7
+	multiple := Color{}
+
+	if lost := 1 - m.avgEF; lost > epsilon {
+		rhoMs := func(rho float64) float64 {
+			return rho * rho * m.avgEF / (1 - rho*(1-m.avgEF))
+		}
+
+		w := math.Max(epsilon, 1-m.singleAlbedo(muO)) * math.Max(epsilon, 1-m.singleAlbedo(muI)) / lost
+		multiple = NewColor(rhoMs(rho.X), rhoMs(rho.Y), rhoMs(rho.Z)).Mul(w)
+	}
+
+	// With this sampling the Lambertian term cancels out, so the weight is the BRDF times pi
+	*attenuation = single.Add(multiple)
+	*scattered = ray.Bounce(rec.P, scatterDirection)
+
+	return true
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"math"
-	"math/rand"
 )
 
 const (
@@ -48,7 +47,7 @@ func _generatePerm() []int {
 		p[i] = i
 	}
 
-	rand.Shuffle(len(p), func(i, j int) { // Permute the array
+	rng.Shuffle(len(p), func(i, j int) { // Permute the array
 		p[i], p[j] = p[j], p[i]
 	})
 
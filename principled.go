@@ -0,0 +1,286 @@
+package main
+
+import "math"
+
+// A principled material combines the lobes of most real surfaces, in the style of the Disney BRDF and of the glTF
+// metallic-roughness model: a metal, a dielectric that reflects some light and diffuses (plastic, wood...) or transmits
+// (glass) the rest, with an optional clear coat on top, sheen (cloth) and emission. Every sample picks one lobe at random,
+// in proportion to the light it reflects, so they can be mixed freely without losing or creating energy.
+type PrincipledMaterial struct {
+	params PrincipledParams
+}
+
+// The parameters of a principled material, the single values are read from the first component of their textures
+type PrincipledParams struct {
+	BaseColor          Texture // Diffuse color of dielectrics, reflectance of metals and tint of the transmitted light
+	Metallic           Texture // 0 is a dielectric, 1 a metal
+	Roughness          Texture // Perceptual roughness of the reflections and of the transmission, 0 is a mirror
+	Transmission       Texture // Fraction of the light that goes through a dielectric instead of being diffused, 1 for glass
+	Emission           Texture // Emitted light, nil for none
+	IOR                float64 // Index of refraction of the dielectric, which also sets how much light it reflects
+	Anisotropy         float64 // In [-1,1], as in ConductorMaterial
+	Clearcoat          float64 // Strength of the clear coat, in [0,1] (other values are clamped)
+	ClearcoatRoughness float64
+	Sheen              float64 // Strength of the sheen, in [0,1], a glow at grazing angles typical of cloth
+	SheenColor         Color   // Color of the sheen, with components in [0,1]
+}
+
+// The index of refraction of the clear coat
+const clearcoatIOR = 1.5
+
+// Fraction of the light scattered by a white diffuse base that the clear coat reflects back inside
+var clearcoatInside = diffuseFresnel(clearcoatIOR)
+
+// Returns the default parameters, a white rough plastic
+func NewPrincipledParams() PrincipledParams {
+	return PrincipledParams{
+		BaseColor:    NewSolidColorTexture(NewColor(0.8, 0.8, 0.8)),
+		Metallic:     NewSolidColorTexture(Color{}),
+		Roughness:    NewSolidColorTexture(NewColor(0.5, 0.5, 0.5)),
+		Transmission: NewSolidColorTexture(Color{}),
+		IOR:          1.5,
+		SheenColor:   NewColor(1, 1, 1)}
+}
+
+func NewPrincipledMaterial(params PrincipledParams) PrincipledMaterial {
+	params.Clearcoat = math.Max(0, math.Min(1, params.Clearcoat))
+
+	return PrincipledMaterial{params: params}
+}
+
+func (m PrincipledMaterial) Emitted(u, v float64, p Point3) Color {
+	if m.params.Emission == nil {
+		return Color{}
+	}
+
+	return m.params.Emission.Value(u, v, p)
+}
+
+func (m PrincipledMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	// The normal faces the ray
+	onb := NewTangentOnb(rec.Normal, rec.Tangent)
+	wo := onb.ToLocal(ray.Direction().UnitVector().Negate())
+
+	if wo.Z <= 0 {
+		return false
+	}
+
+	// The clear coat is a smooth varnish on top of everything (only on the outside), it reflects the light according to
+	// its Fresnel reflectance and lets the rest through, both ways as in CoatedMaterial
+	coated := m.params.Clearcoat > 0 && rec.FrontFace
+
+	if coated {
+		if weight, wi, reflected := sampleCoat(wo, NewGgx(m.params.ClearcoatRoughness, 0), clearcoatIOR, m.params.Clearcoat); reflected {
+			*attenuation = Color{weight, weight, weight}
+			*scattered = ray.Bounce(rec.P, onb.ToWorld(wi))
+			return weight > 0
+		}
+	}
+
+	if !m.scatterBase(ray, rec, onb, wo, attenuation, scattered) {
+		return false
+	}
+
+	if coated {
+		leaveCoat(rec, *scattered, attenuation, clearcoatIOR, m.params.Clearcoat, clearcoatInside)
+	}
+
+	return true
+}
+
+// Scatters the ray on the lobes below the clear coat, wo is the direction towards the viewer in the basis onb
+func (m PrincipledMaterial) scatterBase(ray Ray, rec *HitRecord, onb Onb, wo Vec3, attenuation *Color, scattered *Ray) bool {
+	value := func(t Texture) float64 {
+		return math.Max(0, math.Min(1, t.Value(rec.U, rec.V, rec.P).X))
+	}
+
+	baseColor := m.params.BaseColor.Value(rec.U, rec.V, rec.P)
+	metallic, roughness, transmission := value(m.params.Metallic), value(m.params.Roughness), value(m.params.Transmission)
+
+	// Scatters the ray on a microfacet, in the direction wi
+	bounce := func(ggx Ggx, wi Vec3, weight Color) bool {
+		*attenuation = weight.Mul(ggx.G2(wo, wi) / ggx.G1(wo))
+		*scattered = ray.Bounce(rec.P, onb.ToWorld(wi))
+		return true
+	}
+
+	ggx := NewGgx(roughness, m.params.Anisotropy)
+	h := ggx.SampleVisibleNormal(wo)
+	cosTheta := wo.Dot(h)
+
+	// Metals reflect all the light, the base color is their reflectance at normal incidence (Schlick's approximation)
+	if RandomDouble() < metallic {
+		if wi := Reflect(wo.Negate(), h); wi.Z > 0 {
+			return bounce(ggx, wi, baseColor.Add(Color{1, 1, 1}.Sub(baseColor).Mul(math.Pow(1-cosTheta, 5))))
+		}
+		return false
+	}
+
+	// Dielectrics reflect some of the light
+	eta := m.params.IOR // Index of the other side over the index of the side of the ray
+	if !rec.FrontFace && transmission > 0 {
+		eta = 1 / eta
+	}
+
+	if RandomDouble() < FresnelDielectric(cosTheta, eta) {
+		if wi := Reflect(wo.Negate(), h); wi.Z > 0 {
+			return bounce(ggx, wi, Color{1, 1, 1})
+		}
+		return false
+	}
+
+	// The rest is either transmitted or diffused
+	if RandomDouble() < transmission {
+		if wi := Refract(wo.Negate(), h, 1/eta); wi.Z < 0 {
+			return bounce(ggx, wi, baseColor)
+		}
+		return false
+	}
+
+	direction := rec.Normal.Add(NewRandomUnitVec3())
+
+	if direction.NearZero() {
+		direction = rec.Normal
+	}
+
+	// The sheen is a layer of fibers on top of the diffuse base, which reflects some light where the direction of the
+	// diffused ray is far from the one of the incident ray and lets the rest reach the base, so no light is added
+	wi := onb.ToLocal(direction.UnitVector())
+	cosThetaD := wi.Dot(wi.Add(wo).UnitVector())
+	sheen := m.params.SheenColor.Mul(math.Max(0, math.Min(1, m.params.Sheen)) * math.Pow(1-cosThetaD, 5))
+
+	*attenuation = baseColor.MultiplyByComponent(Color{1, 1, 1}.Sub(sheen)).Add(sheen)
+	*scattered = ray.Bounce(rec.P, direction)
+
+	return true
+}
+
+// The parameters of a material of an MTL file (the companion of OBJ files), including the PBR extension
+type MtlParams struct {
+	Kd      Color   // Diffuse color
+	MapKd   Texture // Diffuse texture, multiplied by Kd, nil for none
+	Ns      float64 // Specular exponent, from 0 (rough) to 1000 (mirror)
+	Ni      float64 // Index of refraction, values <= 1 mean the file doesn't set it
+	D       float64 // Dissolve, 1 is opaque and 0 fully transmissive
+	Ke      Color   // Emission
+	Pr      float64 // Roughness of the PBR extension, negative if the file doesn't set it (Ns is used instead)
+	Pm      float64 // Metallic of the PBR extension, negative if the file doesn't set it
+	Ps      float64 // Sheen
+	Pc, Pcr float64 // Clear coat thickness and roughness
+	MapPr   Texture // Roughness texture, nil for none
+	MapPm   Texture // Metallic texture, nil for none
+	MapKe   Texture // Emission texture, multiplied by Ke, nil for none
+}
+
+// Returns the default values of an MTL material
+func NewMtlParams() MtlParams {
+	return MtlParams{Kd: NewColor(0.8, 0.8, 0.8), D: 1, Pr: -1, Pm: -1}
+}
+
+// Converts the material of an MTL file. The specular color Ks is ignored, as the strength of the reflections of
+// a dielectric comes from its index of refraction.
+func NewMtlMaterial(mtl MtlParams) PrincipledMaterial {
+	params := NewPrincipledParams()
+
+	params.BaseColor = NewSolidColorTexture(mtl.Kd)
+	if mtl.MapKd != nil {
+		params.BaseColor = NewScaledTexture(mtl.MapKd, mtl.Kd)
+	}
+
+	// The Phong exponent of the classic MTL materials is converted to roughness following Walter et al., 2007
+	roughness := mtl.Pr
+	if roughness < 0 {
+		roughness = math.Pow(2/(math.Max(mtl.Ns, 0)+2), 0.25)
+	}
+	params.Roughness = NewSolidColorTexture(NewColor(roughness, roughness, roughness))
+	if mtl.MapPr != nil {
+		params.Roughness = mtl.MapPr
+	}
+
+	metallic := math.Max(mtl.Pm, 0)
+	params.Metallic = NewSolidColorTexture(NewColor(metallic, metallic, metallic))
+	if mtl.MapPm != nil {
+		params.Metallic = mtl.MapPm
+	}
+
+	if mtl.Ni > 1 {
+		params.IOR = mtl.Ni
+	}
+
+	transmission := 1 - math.Max(0, math.Min(1, mtl.D))
+	params.Transmission = NewSolidColorTexture(NewColor(transmission, transmission, transmission))
+
+	if mtl.Ke != (Color{}) {
+		params.Emission = NewSolidColorTexture(mtl.Ke)
+		if mtl.MapKe != nil {
+			params.Emission = NewScaledTexture(mtl.MapKe, mtl.Ke)
+		}
+	}
+
+	params.Sheen = mtl.Ps
+	params.Clearcoat, params.ClearcoatRoughness = mtl.Pc, mtl.Pcr
+
+	return NewPrincipledMaterial(params)
+}
+
+// The parameters of a glTF metallic-roughness material, with the extensions for transmission, index of refraction,
+// clear coat, sheen and emission strength. As in glTF files, the base color and emissive textures hold sRGB colors, which
+// are converted to linear colors, while the other textures hold linear values.
+type GltfParams struct {
+	BaseColorFactor          Color
+	BaseColorTexture         Texture // nil for none
+	MetallicFactor           float64
+	RoughnessFactor          float64
+	MetallicRoughnessTexture Texture // Roughness in the green channel and metallic in the blue channel, nil for none
+	EmissiveFactor           Color
+	EmissiveTexture          Texture // nil for none
+	EmissiveStrength         float64
+	TransmissionFactor       float64
+	IOR                      float64
+	ClearcoatFactor          float64
+	ClearcoatRoughnessFactor float64
+	SheenColorFactor         Color
+}
+
+// Returns the default values of the glTF specification
+func NewGltfParams() GltfParams {
+	return GltfParams{BaseColorFactor: NewColor(1, 1, 1), MetallicFactor: 1, RoughnessFactor: 1, EmissiveStrength: 1, IOR: 1.5}
+}
+
+// Converts a glTF material
+func NewGltfMaterial(gltf GltfParams) PrincipledMaterial {
+	params := NewPrincipledParams()
+
+	params.BaseColor = NewSolidColorTexture(gltf.BaseColorFactor)
+	if gltf.BaseColorTexture != nil {
+		params.BaseColor = NewScaledTexture(NewSRGBTexture(gltf.BaseColorTexture), gltf.BaseColorFactor)
+	}
+
+	metallic := NewColor(gltf.MetallicFactor, gltf.MetallicFactor, gltf.MetallicFactor)
+	roughness := NewColor(gltf.RoughnessFactor, gltf.RoughnessFactor, gltf.RoughnessFactor)
+	params.Metallic, params.Roughness = NewSolidColorTexture(metallic), NewSolidColorTexture(roughness)
+
+	if gltf.MetallicRoughnessTexture != nil {
+		params.Metallic = NewScaledTexture(NewChannelTexture(gltf.MetallicRoughnessTexture, 2), metallic)
+		params.Roughness = NewScaledTexture(NewChannelTexture(gltf.MetallicRoughnessTexture, 1), roughness)
+	}
+
+	if emission := gltf.EmissiveFactor.Mul(gltf.EmissiveStrength); emission != (Color{}) {
+		params.Emission = NewSolidColorTexture(emission)
+		if gltf.EmissiveTexture != nil {
+			params.Emission = NewScaledTexture(NewSRGBTexture(gltf.EmissiveTexture), emission)
+		}
+	}
+
+	transmission := gltf.TransmissionFactor
+	params.Transmission = NewSolidColorTexture(NewColor(transmission, transmission, transmission))
+	params.IOR = gltf.IOR
+
+	params.Clearcoat, params.ClearcoatRoughness = gltf.ClearcoatFactor, gltf.ClearcoatRoughnessFactor
+
+	if gltf.SheenColorFactor != (Color{}) {
+		params.Sheen, params.SheenColor = 1, gltf.SheenColorFactor
+	}
+
+	return NewPrincipledMaterial(params)
+}
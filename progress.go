@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Number of rays traced so far by all the renders
+var raysTraced atomic.Int64
+
+// Describes how far a render has gone, samples are counted over the whole render while pixels only over the current pass
+type Progress struct {
+	Pass         int // Passes are numbered from 1
+	Passes       int // Zero if unknown, e.g. when rendering for a given time
+	PixelsDone   int
+	PixelsTotal  int
+	SamplesDone  int64
+	SamplesTotal int64
+	RaysTraced   int64
+	Elapsed      time.Duration
+	Remaining    time.Duration // Estimated time to the end of the render
+}
+
+type ProgressFunc func(p Progress)
+
+type progressTracker struct {
+	progress     Progress
+	start        time.Time
+	startRays    int64
+	startSamples int64     // Samples that were already done when tracking started, e.g. by a resumed render
+	deadline     time.Time // If set, the render ends at this time rather than after a number of samples
+	callback     ProgressFunc
+}
+
+func newProgressTracker(passes, pixels int, samplesPerPass int64, callback ProgressFunc) *progressTracker {
+	return &progressTracker{
+		progress:  Progress{Passes: passes, PixelsTotal: pixels, SamplesTotal: int64(passes) * samplesPerPass},
+		start:     time.Now(),
+		startRays: raysTraced.Load(),
+		callback:  callback}
+}
+
+// Skips the passes that were completed before tracking started
+func (pt *progressTracker) skipPasses(passes int, samples int64) {
+	pt.progress.Pass = passes
+	pt.progress.SamplesDone = samples
+	pt.startSamples = samples
+}
+
+func (pt *progressTracker) startPass() {
+	pt.progress.Pass++
+	pt.progress.PixelsDone = 0
+}
+
+func (pt *progressTracker) update(pixels int, samples int64) {
+	pt.progress.PixelsDone += pixels
+	pt.progress.SamplesDone += samples
+
+	if pt.callback == nil {
+		return
+	}
+
+	pt.progress.RaysTraced = raysTraced.Load() - pt.startRays
+	pt.progress.Elapsed = time.Since(pt.start)
+
+	if !pt.deadline.IsZero() {
+		pt.progress.Remaining = time.Until(pt.deadline)
+	} else if samplesDone := pt.progress.SamplesDone - pt.startSamples; samplesDone > 0 {
+		remainingSamples := pt.progress.SamplesTotal - pt.progress.SamplesDone
+		pt.progress.Remaining = time.Duration(float64(pt.progress.Elapsed) * float64(remainingSamples) / float64(samplesDone))
+	}
+
+	pt.callback(pt.progress)
+}
+
+// Prints the progress of a render on stderr
+func PrintProgress(p Progress) {
+	pass, samples := "", ""
+
+	if p.Passes > 1 {
+		pass = fmt.Sprintf("pass %d of %d, ", p.Pass, p.Passes)
+	} else if p.Passes == 0 {
+		pass = fmt.Sprintf("pass %d, ", p.Pass)
+	}
+
+	if p.SamplesTotal > 0 {
+		samples = fmt.Sprintf("%d%% of samples, ", p.SamplesDone*100/p.SamplesTotal)
+	}
+
+	fmt.Fprintf(os.Stderr, "Rendering %s%d%% of pixels, %s%d rays traced, %v remaining\n",
+		pass, p.PixelsDone*100/p.PixelsTotal, samples, p.RaysTraced, p.Remaining.Round(time.Second))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRenderCancel(t *testing.T) {
+	cam, world := newSmallScene(t, 40)
+	const samples, scanlines = 2, 3
+
+	// Cancels the render after a few scanlines
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	progress := func(p Progress) {
+		calls++
+		if calls == scanlines {
+			cancel()
+		}
+	}
+
+	SeedRandom(1)
+
+	film := NewFilm(cam.imageWidth, cam.imageHeight)
+	if err := cam.RenderContext(ctx, world, film, samples, progress); err != context.Canceled {
+		t.Fatalf("the cancelled render returned %v, want %v", err, context.Canceled)
+	}
+
+	if calls != scanlines {
+		t.Errorf("the render went on for %d scanlines after it was cancelled", calls-scanlines)
+	}
+
+	// The scanlines rendered before the cancellation are complete, the others are empty
+	for y := 0; y < film.Height; y++ {
+		want := 0
+		if y < scanlines {
+			want = samples
+		}
+
+		for x := 0; x < film.Width; x++ {
+			if count := film.Count[x+y*film.Width]; count != want {
+				t.Fatalf("pixel %d,%d has %d samples, want %d", x, y, count, want)
+			}
+		}
+	}
+
+	if pixel := film.Pixel(0, 0); pixel == (Color{}) {
+		t.Errorf("the first pixel of the partial film is black")
+	}
+}
+
+func TestRenderProgress(t *testing.T) {
+	cam, world := newSmallScene(t, 40)
+	const samples = 2
+
+	var last Progress
+	calls := 0
+	progress := func(p Progress) {
+		calls++
+
+		if p.PixelsDone != calls*cam.imageWidth || p.SamplesDone != int64(p.PixelsDone*samples) {
+			t.Fatalf("after %d scanlines the progress is %d pixels and %d samples", calls, p.PixelsDone, p.SamplesDone)
+		}
+
+		last = p
+	}
+
+	SeedRandom(1)
+
+	film := NewFilm(cam.imageWidth, cam.imageHeight)
+	if err := cam.RenderContext(context.Background(), world, film, samples, progress); err != nil {
+		t.Fatal(err)
+	}
+
+	pixels := cam.imageWidth * cam.imageHeight
+	want := Progress{Pass: 1, Passes: 1, PixelsDone: pixels, PixelsTotal: pixels, SamplesDone: int64(pixels * samples), SamplesTotal: int64(pixels * samples)}
+
+	if calls != cam.imageHeight || last.Pass != want.Pass || last.Passes != want.Passes || last.PixelsDone != want.PixelsDone ||
+		last.PixelsTotal != want.PixelsTotal || last.SamplesDone != want.SamplesDone || last.SamplesTotal != want.SamplesTotal {
+		t.Errorf("the progress at the end of the render is %+v after %d calls, want %+v after %d calls", last, calls, want, cam.imageHeight)
+	}
+
+	if last.Remaining != 0 || last.RaysTraced < int64(pixels*samples) {
+		t.Errorf("at the end of the render %v remain and %d rays were traced", last.Remaining, last.RaysTraced)
+	}
+}
+
+func TestProgressRemaining(t *testing.T) {
+	var last Progress
+	callback := func(p Progress) { last = p }
+
+	// Half of the samples took 10 seconds, the other half takes as long
+	tracker := newProgressTracker(2, 100, 100, callback)
+	tracker.start = time.Now().Add(-10 * time.Second)
+	tracker.startPass()
+	tracker.update(100, 100)
+
+	if d := last.Remaining - 10*time.Second; d < -time.Second || d > time.Second {
+		t.Errorf("halfway through the render %v remain, want 10s", last.Remaining)
+	}
+
+	// The passes of a resumed render don't count for the speed: one of four passes took 10 seconds, two are left
+	tracker = newProgressTracker(4, 100, 100, callback)
+	tracker.skipPasses(1, 100)
+	tracker.start = time.Now().Add(-10 * time.Second)
+	tracker.startPass()
+	tracker.update(100, 100)
+
+	if last.Pass != 2 || last.SamplesDone != 200 {
+		t.Errorf("the resumed render is at pass %d with %d samples, want pass 2 with 200 samples", last.Pass, last.SamplesDone)
+	}
+
+	if d := last.Remaining - 20*time.Second; d < -time.Second || d > time.Second {
+		t.Errorf("after one pass of a resumed render %v remain, want 20s", last.Remaining)
+	}
+
+	// With a deadline, the remaining time is the time to the deadline
+	tracker = newProgressTracker(0, 100, 100, callback)
+	tracker.deadline = time.Now().Add(time.Minute)
+	tracker.startPass()
+	tracker.update(50, 50)
+
+	if d := last.Remaining - time.Minute; d < -time.Second || d > 0 {
+		t.Errorf("a minute before the deadline %v remain", last.Remaining)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Identifies the scene a progressive render belongs to: the same image built with the same seed is always the same scene
+type SceneIdentity struct {
+	ImageNo      int
+	Seed         int64
+	Width        int
+	Height       int
+	ApertureMask string // Image file of the aperture shape that replaces the one of the scene, empty for none
+	Spectral     bool   // Rendered with wavelengths instead of RGB colors
+}
+
+// A checkpoint stores everything needed to resume a progressive render
+type Checkpoint struct {
+	Scene      SceneIdentity
+	SampleSeed int64 // The passes are seeded from it, so renders with different sample seeds have independent samples
+	Passes     int   // Number of completed passes
+	Film       *Film
+	Clamp      float64  // Maximum brightness of a sample, 0 means no limit
+	Layers     []string // Auxiliary outputs recorded by the film, the resumed render must record the same ones
+}
+
+// The random generator is reseeded at the start of every pass, so the sample seed and the number of completed passes
+// are all that's needed to restore its state. The pass seeds are hashed (SplitMix64), so that they don't repeat the
+// sample seed of another render, nor the pass seeds of a render with a nearby sample seed.
+func passSeed(seed int64, pass int) int64 {
+	z := uint64(seed) + uint64(pass+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return int64(z ^ (z >> 31))
+}
+
+func LoadCheckpoint(filename string) (*Checkpoint, error) {
+	f, err := os.Open(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	checkpoint := &Checkpoint{}
+
+	if err := gob.NewDecoder(f).Decode(checkpoint); err != nil {
+		return nil, fmt.Errorf("cannot decode checkpoint %s: %w", filename, err)
+	}
+
+	return checkpoint, nil
+}
+
+// Saves the checkpoint to a temporary file first, so that a crash while saving doesn't destroy the previous checkpoint
+func (checkpoint *Checkpoint) Save(filename string) error {
+	tempFilename := filename + ".tmp"
+
+	f, err := os.Create(tempFilename)
+
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewEncoder(f).Encode(checkpoint); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilename, filename)
+}
+
+type ProgressiveOptions struct {
+	Passes       int           // Stop after this number of passes, including those of a resumed render
+	TimeBudget   time.Duration // If not zero, keep adding passes until the time runs out (Passes is ignored)
+	Checkpoint   string        // If not empty, the checkpoint is saved to this file...
+	SaveInterval time.Duration // ...every SaveInterval and at the end of the render
+	Progress     ProgressFunc  // If not nil, it's called after every scanline
+}
+
+// Renders the scene one sample per pixel at a time, accumulating the passes into the checkpoint film.
+// With a time budget, a new pass is only started if it's expected to end before the time runs out.
+// If the context is cancelled the render stops and returns the context error, the film also holds the samples of the interrupted pass
+// but the saved checkpoint doesn't.
+func (camera *Camera) RenderProgressive(ctx context.Context, world Hittable, checkpoint *Checkpoint, options ProgressiveOptions) error {
+	start := time.Now()
+	lastSave := start
+
+	pixels := checkpoint.Film.Width * checkpoint.Film.Height
+	passes := options.Passes
+
+	var deadline time.Time
+	if options.TimeBudget > 0 {
+		deadline = start.Add(options.TimeBudget)
+		passes = 0 // Unknown
+	}
+
+	tracker := newProgressTracker(passes, pixels, int64(pixels), options.Progress)
+	tracker.skipPasses(checkpoint.Passes, int64(checkpoint.Passes*pixels))
+	tracker.deadline = deadline
+
+	for renderedPasses := 0; ; renderedPasses++ {
+		if deadline.IsZero() && checkpoint.Passes >= passes {
+			break
+		}
+
+		if !deadline.IsZero() && renderedPasses > 0 {
+			averagePass := time.Since(start) / time.Duration(renderedPasses)
+
+			if time.Now().Add(averagePass).After(deadline) {
+				break
+			}
+		}
+
+		SeedRandom(passSeed(checkpoint.SampleSeed, checkpoint.Passes))
+
+		if err := camera.renderPass(ctx, world, checkpoint.Film, 1, tracker); err != nil {
+			return err
+		}
+
+		checkpoint.Passes++
+
+		if options.Checkpoint != "" && time.Since(lastSave) >= options.SaveInterval {
+			if err := checkpoint.Save(options.Checkpoint); err != nil {
+				return err
+			}
+
+			lastSave = time.Now()
+		}
+	}
+
+	if options.Checkpoint != "" {
+		return checkpoint.Save(options.Checkpoint)
+	}
+
+	return nil
+}
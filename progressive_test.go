@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Returns image 2 rendered at the given width, small enough for the tests
+func newSmallScene(t *testing.T, width int) (Camera, Hittable) {
+	t.Helper()
+
+	cam, world, err := buildScene(SceneIdentity{ImageNo: 2, Seed: 1}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cam.SetImageWidth(width)
+	cam.Initialize()
+
+	return cam, world
+}
+
+func TestResumeCheckpoint(t *testing.T) {
+	cam, world := newSmallScene(t, 40)
+	const passes, sampleSeed = 4, 42
+
+	// The whole render at once
+	want := &Checkpoint{SampleSeed: sampleSeed, Film: NewFilm(cam.imageWidth, cam.imageHeight)}
+	if err := cam.RenderProgressive(context.Background(), world, want, ProgressiveOptions{Passes: passes}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Half of the passes, then the render is resumed from the saved checkpoint
+	filename := filepath.Join(t.TempDir(), "checkpoint")
+	half := &Checkpoint{SampleSeed: sampleSeed, Film: NewFilm(cam.imageWidth, cam.imageHeight)}
+	if err := cam.RenderProgressive(context.Background(), world, half, ProgressiveOptions{Passes: passes / 2, Checkpoint: filename}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := LoadCheckpoint(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cam.RenderProgressive(context.Background(), world, resumed, ProgressiveOptions{Passes: passes}); err != nil {
+		t.Fatal(err)
+	}
+
+	if resumed.Passes != passes || !slices.Equal(resumed.Film.Sum, want.Film.Sum) || !slices.Equal(resumed.Film.Count, want.Film.Count) {
+		t.Errorf("the resumed render has %d passes and differs from the render done at once", resumed.Passes)
+	}
+}
+
+func TestResumeChecksOptions(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "checkpoint")
+
+	cam, _, err := buildScene(SceneIdentity{ImageNo: 2, Seed: 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity := SceneIdentity{ImageNo: 2, Seed: 1, Width: cam.imageWidth, Height: cam.imageHeight}
+	checkpoint := &Checkpoint{Scene: identity, SampleSeed: 42, Film: NewFilm(cam.imageWidth, cam.imageHeight), Clamp: 10, Passes: 1}
+	if err := checkpoint.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		options Options
+		resumed bool
+	}{
+		{Options{}, true},
+		{Options{clamp: 10}, true},
+		{Options{clamp: 5}, false},
+		{Options{aov: AovDepth}, false},
+		{Options{denoise: true}, false},
+	} {
+		options := test.options
+		options.resume, options.checkpoint, options.output, options.samplesPerPixel = true, filename, filepath.Join(dir, "out.ppm"), 1
+
+		if err := run(2, options); (err == nil) != test.resumed {
+			t.Errorf("resuming a checkpoint rendered with -clamp 10 and no AOVs with %+v returned %v, want resumed %v", test.options, err, test.resumed)
+		}
+	}
+}
+
+func TestPassSeeds(t *testing.T) {
+	// The samples of every pass of renders with nearby sample seeds, and of plain renders with those seeds, are different
+	seeds := map[int64]string{}
+
+	for sampleSeed := int64(1000); sampleSeed < 1000+10000; sampleSeed++ {
+		seeds[sampleSeed] = "a plain render"
+	}
+
+	for sampleSeed := int64(1000); sampleSeed < 1000+100; sampleSeed++ {
+		for pass := 0; pass < 100; pass++ {
+			seed := passSeed(sampleSeed, pass)
+
+			if other, ok := seeds[seed]; ok {
+				t.Fatalf("pass %d of the render with sample seed %d has the same seed as %s", pass, sampleSeed, other)
+			}
+
+			seeds[seed] = "another pass"
+		}
+	}
+}
+
+func TestRenderTimeBudget(t *testing.T) {
+	cam, world := newSmallScene(t, 40)
+
+	for _, budget := range []time.Duration{time.Nanosecond, 30 * time.Millisecond} {
+		deadline := time.Now().Add(budget)
+
+		// A pass starts when the previous one ends
+		var starts []time.Time
+		progress := func(p Progress) {
+			if p.PixelsDone == p.PixelsTotal {
+				starts = append(starts, time.Now())
+			}
+		}
+
+		checkpoint := &Checkpoint{SampleSeed: 42, Film: NewFilm(cam.imageWidth, cam.imageHeight)}
+		options := ProgressiveOptions{Passes: 1000, TimeBudget: budget, Progress: progress}
+
+		if err := cam.RenderProgressive(context.Background(), world, checkpoint, options); err != nil {
+			t.Fatal(err)
+		}
+
+		if checkpoint.Passes < 1 || checkpoint.Passes != len(starts) {
+			t.Errorf("with a budget of %v %d passes were rendered and %d ended, want at least one", budget, checkpoint.Passes, len(starts))
+		}
+
+		// The last pass end is not the start of a pass
+		for i, start := range starts[:len(starts)-1] {
+			if start.After(deadline) {
+				t.Errorf("with a budget of %v pass %d started %v after the deadline", budget, i+2, start.Sub(deadline))
+			}
+		}
+
+		if checkpoint.Film.Samples() != checkpoint.Passes {
+			t.Errorf("with a budget of %v the film has %d samples per pixel after %d passes", budget, checkpoint.Film.Samples(), checkpoint.Passes)
+		}
+	}
+}
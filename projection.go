@@ -0,0 +1,85 @@
+package main
+
+import "math"
+
+// Camera projections, they map the pixels of the image to the directions of the camera rays
+const (
+	ProjectionPerspective     = iota // Pinhole or thin lens camera
+	ProjectionOrthographic           // Parallel rays, the viewport is as large as the perspective viewport at the focus distance
+	ProjectionFisheye                // Equidistant fisheye, the angle from the view direction grows linearly with the distance from the image center, the vertical field of view spans the image height, up to 180 degrees
+	ProjectionEquirectangular        // Full 360x180 degrees panorama, longitude along the image width and latitude along the image height (use a 2:1 aspect ratio)
+)
+
+// All the rays are parallel to the view direction, they start from the plane of the camera center
+func (camera Camera) getOrthographicRay(i, j int, eye float64) Ray {
+	pixelCenter := camera.pixelUpperLeft.Add(camera.pixelDelta_U.Mul(float64(i))).Add(camera.pixelDelta_V.Mul(float64(j)))
+	pixelSample := pixelCenter.Add(camera.getRandomPointInPixelSquare())
+
+	origin := pixelSample.Add(camera.w.Mul(camera.focusPlane)).Add(camera.eyeOffset(eye))
+	time := camera.shutter.sampleTime(j, camera.eyeHeight)
+
+	return NewRay(origin, camera.w.Negate(), time)
+}
+
+// Rays of the fisheye and equirectangular projections, the lens (if any) focuses on a sphere around the camera center
+func (camera Camera) getPanoramicRay(i, j int, eye float64) (Ray, bool) {
+	x := float64(i) + RandomDouble()
+	y := float64(j) + RandomDouble()
+
+	var direction Vec3
+	var ok bool
+
+	if camera.projection == ProjectionFisheye {
+		direction, ok = camera.fisheyeDirection(x, y)
+	} else {
+		direction, ok = camera.equirectangularDirection(x, y)
+	}
+
+	if !ok {
+		return Ray{}, false
+	}
+
+	origin := camera.lookFrom
+	if eye != 0 && camera.projection == ProjectionEquirectangular {
+		origin = origin.Add(camera.odsOffset(direction, eye))
+	} else {
+		origin = origin.Add(camera.eyeOffset(eye))
+	}
+	if camera.hasAperture() {
+		focus := origin.Add(direction.Mul(camera.focusPlane))
+		if origin, ok = camera.getRandomPointInAperture(origin, i, j); !ok {
+			return Ray{}, false
+		}
+		direction = focus.Sub(origin)
+	}
+	time := camera.shutter.sampleTime(j, camera.eyeHeight)
+
+	return NewRay(origin, direction, time), true
+}
+
+// Returns the unit direction for the point x, y of the image, in pixels, or false if it's more than 90 degrees from the view direction
+func (camera Camera) fisheyeDirection(x, y float64) (Vec3, bool) {
+	dx := x - float64(camera.eyeWidth)/2
+	dy := float64(camera.eyeHeight)/2 - y
+
+	// Angle from the view direction, the lens sees at most the hemisphere in front of it (a 180 degrees field of view)
+	anglePerPixel := DegreesToRadians(camera.vfov) / float64(camera.eyeHeight)
+	theta := math.Sqrt(dx*dx+dy*dy) * anglePerPixel
+	if theta > math.Pi/2 {
+		return Vec3{}, false
+	}
+
+	phi := math.Atan2(dy, dx)
+	sinTheta := math.Sin(theta)
+
+	return camera.u.Mul(sinTheta * math.Cos(phi)).Add(camera.v.Mul(sinTheta * math.Sin(phi))).Sub(camera.w.Mul(math.Cos(theta))), true
+}
+
+// Returns the unit direction for the point x, y of the image, in pixels, the center of the image looks at the "look at" point
+func (camera Camera) equirectangularDirection(x, y float64) (Vec3, bool) {
+	longitude := (x/float64(camera.eyeWidth) - 0.5) * 2 * math.Pi
+	latitude := (0.5 - y/float64(camera.eyeHeight)) * math.Pi
+	cosLatitude := math.Cos(latitude)
+
+	return camera.u.Mul(cosLatitude * math.Sin(longitude)).Add(camera.v.Mul(math.Sin(latitude))).Sub(camera.w.Mul(cosLatitude * math.Cos(longitude))), true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns a camera at the origin looking down -z, initialized for a square image of the given size
+func newProjectionCamera(projection int, vfov float64, width, height int) Camera {
+	cam := NewCamera()
+	cam.SetProjection(projection)
+	cam.SetVerticalFieldOfView(vfov)
+	cam.SetImageWidth(width)
+	cam.SetAspectRatio(float64(width) / float64(height))
+	cam.Initialize()
+
+	return cam
+}
+
+func TestFisheyeDirection(t *testing.T) {
+	cam := newProjectionCamera(ProjectionFisheye, 180, 100, 100)
+
+	if got, ok := cam.fisheyeDirection(50, 50); !ok || !nearVec3(got, NewVec3(0, 0, -1), 1e-9) {
+		t.Errorf("the image center looks towards %v, want %v", got, NewVec3(0, 0, -1))
+	}
+
+	// The angle grows linearly with the distance from the center, the edge of the image is 90 degrees from the view direction
+	if got, ok := cam.fisheyeDirection(75, 50); !ok || !nearVec3(got, NewVec3(math.Sqrt(0.5), 0, -math.Sqrt(0.5)), 1e-9) {
+		t.Errorf("halfway to the right edge looks towards %v, want 45 degrees to the right", got)
+	}
+
+	// The corners are beyond the hemisphere in front of the lens
+	if got, ok := cam.fisheyeDirection(0, 0); ok {
+		t.Errorf("the corner looks towards %v, want no direction", got)
+	}
+
+	// A wider field of view is limited to the hemisphere too
+	wide := newProjectionCamera(ProjectionFisheye, 270, 100, 100)
+	if got, ok := wide.fisheyeDirection(100, 50); ok {
+		t.Errorf("the right edge of a 270 degrees fisheye looks towards %v, want no direction", got)
+	}
+}
+
+func TestEquirectangularDirection(t *testing.T) {
+	cam := newProjectionCamera(ProjectionEquirectangular, 90, 200, 100)
+
+	for _, test := range []struct {
+		name string
+		x, y float64
+		want Vec3
+	}{
+		{"center", 100, 50, NewVec3(0, 0, -1)},
+		{"left edge", 0, 50, NewVec3(0, 0, 1)},
+		{"right quarter", 150, 50, NewVec3(1, 0, 0)},
+		{"top", 100, 0, NewVec3(0, 1, 0)},
+	} {
+		if got, ok := cam.equirectangularDirection(test.x, test.y); !ok || !nearVec3(got, test.want, 1e-9) {
+			t.Errorf("the %s looks towards %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestOrthographicRays(t *testing.T) {
+	SeedRandom(1)
+
+	cam := newProjectionCamera(ProjectionOrthographic, 90, 20, 10)
+
+	for _, pixel := range [][2]int{{0, 0}, {10, 5}, {19, 9}} {
+		ray, ok := cam.getRay(pixel[0], pixel[1])
+		if !ok || !nearVec3(ray.Direction(), NewVec3(0, 0, -1), 1e-9) {
+			t.Errorf("the ray of pixel %v goes towards %v, want %v", pixel, ray.Direction(), NewVec3(0, 0, -1))
+		}
+
+		// The rays start from the plane of the camera center
+		if math.Abs(ray.Origin().Z) > 1e-9 {
+			t.Errorf("the ray of pixel %v starts from %v, want z = 0", pixel, ray.Origin())
+		}
+	}
+}
@@ -55,6 +55,7 @@ func (quad Quad) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
 		rec.SetFaceNormal(ray, quad.normal)
 		rec.U = alpha
 		rec.V = beta
+		rec.Tangent = quad.u
 
 		return true
 	}
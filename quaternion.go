@@ -0,0 +1,89 @@
+package main
+
+import "math"
+
+// A unit quaternion represents a rotation, it's used to interpolate smoothly between two rotations
+type Quaternion struct {
+	W       float64
+	X, Y, Z float64
+}
+
+func IdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// Rotation around an arbitrary axis, counterclockwise when looking from the tip of the axis (same as NewRotation)
+func NewQuaternion(axis Vec3, angleInDegrees float64) Quaternion {
+	a := axis.UnitVector()
+	halfTheta := DegreesToRadians(angleInDegrees) / 2
+	sin := math.Sin(halfTheta)
+
+	return Quaternion{W: math.Cos(halfTheta), X: a.X * sin, Y: a.Y * sin, Z: a.Z * sin}
+}
+
+// Extracts the rotation from a matrix whose upper 3x3 part is a pure rotation
+func NewQuaternionFromMat4(m Mat4) Quaternion {
+	trace := m[0][0] + m[1][1] + m[2][2]
+
+	var q Quaternion
+
+	// Choose the computation that avoids dividing by a small number
+	if trace > 0 {
+		s := 2 * math.Sqrt(trace+1)
+		q = Quaternion{W: s / 4, X: (m[2][1] - m[1][2]) / s, Y: (m[0][2] - m[2][0]) / s, Z: (m[1][0] - m[0][1]) / s}
+	} else if m[0][0] > m[1][1] && m[0][0] > m[2][2] {
+		s := 2 * math.Sqrt(1+m[0][0]-m[1][1]-m[2][2])
+		q = Quaternion{W: (m[2][1] - m[1][2]) / s, X: s / 4, Y: (m[0][1] + m[1][0]) / s, Z: (m[0][2] + m[2][0]) / s}
+	} else if m[1][1] > m[2][2] {
+		s := 2 * math.Sqrt(1+m[1][1]-m[0][0]-m[2][2])
+		q = Quaternion{W: (m[0][2] - m[2][0]) / s, X: (m[0][1] + m[1][0]) / s, Y: s / 4, Z: (m[1][2] + m[2][1]) / s}
+	} else {
+		s := 2 * math.Sqrt(1+m[2][2]-m[0][0]-m[1][1])
+		q = Quaternion{W: (m[1][0] - m[0][1]) / s, X: (m[0][2] + m[2][0]) / s, Y: (m[1][2] + m[2][1]) / s, Z: s / 4}
+	}
+
+	return q.Normalize()
+}
+
+func (q Quaternion) Dot(r Quaternion) float64 {
+	return q.W*r.W + q.X*r.X + q.Y*r.Y + q.Z*r.Z
+}
+
+func (q Quaternion) Normalize() Quaternion {
+	l := math.Sqrt(q.Dot(q))
+	return Quaternion{W: q.W / l, X: q.X / l, Y: q.Y / l, Z: q.Z / l}
+}
+
+// Spherical linear interpolation, it rotates at constant speed along the shortest path from q (t=0) to r (t=1)
+func (q Quaternion) Slerp(r Quaternion, t float64) Quaternion {
+	cosTheta := q.Dot(r)
+
+	// q and -q are the same rotation, pick the one that takes the shortest path
+	if cosTheta < 0 {
+		r = Quaternion{W: -r.W, X: -r.X, Y: -r.Y, Z: -r.Z}
+		cosTheta = -cosTheta
+	}
+
+	var a, b float64
+
+	if cosTheta > 0.9995 {
+		// The rotations are very close, a linear interpolation is accurate and avoids dividing by sin(theta) ~ 0
+		a, b = 1-t, t
+	} else {
+		theta := math.Acos(cosTheta)
+		sinTheta := math.Sin(theta)
+		a, b = math.Sin((1-t)*theta)/sinTheta, math.Sin(t*theta)/sinTheta
+	}
+
+	return Quaternion{W: a*q.W + b*r.W, X: a*q.X + b*r.X, Y: a*q.Y + b*r.Y, Z: a*q.Z + b*r.Z}.Normalize()
+}
+
+func (q Quaternion) Mat4() Mat4 {
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+
+	return Mat4{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y), 0},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x), 0},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1}}
+}
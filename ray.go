@@ -1,5 +1,7 @@
 package main
 
+import "math"
+
 // A ray (i.e. a line) is defined by a point (its origin) and a vector (its direction): ray(t) = origin + t*direction
 //
 // The t parameter allows access to every point on the ray. Usually the term "ray" implies that t > 0, which generates a half-line.
@@ -7,6 +9,10 @@ type Ray struct {
 	orig Point3
 	dir  Vec3
 	tm   float64
+
+	wavelength float64 // In nanometers, 0 if the ray carries all the wavelengths (i.e. RGB colors)
+	spectral   bool    // If true, wavelength is the hero wavelength of a spectral ray: see Wavelengths()
+	heroOnly   bool    // If true, the spectral ray carries only the hero wavelength
 }
 
 func NewRay(origin Point3, direction Vec3, time float64) Ray {
@@ -28,3 +34,54 @@ func (r Ray) Direction() Vec3 {
 func (r Ray) Time() float64 {
 	return r.tm
 }
+
+func (r Ray) Wavelength() float64 {
+	return r.wavelength
+}
+
+// Returns the same ray, carrying only the given wavelength
+func (r Ray) WithWavelength(wavelength float64) Ray {
+	r.wavelength = wavelength
+	return r
+}
+
+// Returns the same ray, as a spectral ray with the given hero wavelength
+func (r Ray) WithHeroWavelength(hero float64) Ray {
+	r.wavelength = hero
+	r.spectral = true
+	return r
+}
+
+// Returns true if the components of the colors computed along the ray are the values at its three wavelengths,
+// instead of RGB components
+func (r Ray) IsSpectral() bool {
+	return r.spectral
+}
+
+// Returns the three wavelengths of a spectral ray: the hero wavelength and two more, evenly spaced in the visible range
+func (r Ray) Wavelengths() Vec3 {
+	span := WavelengthMax - WavelengthMin
+	rotate := func(offset float64) float64 {
+		return WavelengthMin + math.Mod(r.wavelength-WavelengthMin+offset, span)
+	}
+
+	return NewVec3(r.wavelength, rotate(span/3), rotate(2*span/3))
+}
+
+// Drops the other wavelengths of a spectral ray, for materials that send every wavelength in a different direction.
+// Returns the ray and the weight of its path: the hero wavelength now accounts for the other ones too.
+func (r Ray) KeepHeroWavelength() (Ray, Color) {
+	if r.heroOnly {
+		return r, Color{1, 0, 0}
+	}
+
+	r.heroOnly = true
+
+	return r, Color{wavelengthsPdf(r.Wavelengths()) / wavelengthPdf(r.wavelength), 0, 0}
+}
+
+// Returns the ray that continues the path of this one after a bounce, it keeps the time and the wavelength
+func (r Ray) Bounce(origin Point3, direction Vec3) Ray {
+	r.orig, r.dir = origin, direction
+	return r
+}
@@ -0,0 +1,128 @@
+package main
+
+import "math"
+
+// A dielectric (glass, water...) material with a GGX microfacet surface, that looks frosted when rough. It uses the exact
+// Fresnel equations instead of Schlick's approximation, and can absorb the light that travels inside it, which colors
+// thick parts more than thin ones (Beer-Lambert law), and can disperse the light. The absorption assumes that the objects don't overlap and that
+// the camera is outside them.
+type RoughDielectricMaterial struct {
+	BlackEmitter
+	ir         float64
+	roughness  Texture    // Perceptual roughness in [0,1], from the first component of the texture: 0 is smooth glass
+	absorption Color      // Absorption coefficient per unit of distance, for every component
+	dispersion Dispersion // If not nil, replaces ir with an index of refraction that depends on the wavelength
+}
+
+func NewRoughDielectricMaterial(indexOfRefraction, roughness float64) RoughDielectricMaterial {
+	return NewTextureRoughDielectricMaterial(indexOfRefraction, NewSolidColorTexture(NewColor(roughness, roughness, roughness)))
+}
+
+func NewTextureRoughDielectricMaterial(indexOfRefraction float64, roughness Texture) RoughDielectricMaterial {
+	return RoughDielectricMaterial{ir: indexOfRefraction, roughness: roughness}
+}
+
+// Glass that splits white light into its colors, as the index of refraction depends on the wavelength
+func NewDispersiveMaterial(dispersion Dispersion, roughness float64) RoughDielectricMaterial {
+	m := NewRoughDielectricMaterial(dispersion.IndexOfRefraction(589.3), roughness)
+	m.dispersion = dispersion
+	return m
+}
+
+// Colored glass: the light that travels the given distance inside the material keeps the fraction tint of its color
+func NewTintedRoughDielectricMaterial(indexOfRefraction float64, roughness Texture, tint Color, distance float64) RoughDielectricMaterial {
+	m := NewTextureRoughDielectricMaterial(indexOfRefraction, roughness)
+	m.absorption = NewColor(-math.Log(tint.X)/distance, -math.Log(tint.Y)/distance, -math.Log(tint.Z)/distance)
+	return m
+}
+
+func (m RoughDielectricMaterial) Scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	weight := Color{1, 1, 1}
+
+	// The index of refraction depends on the wavelength, so the ray must carry a single one
+	if m.dispersion != nil && ray.Wavelength() == 0 {
+		ray = ray.WithWavelength(SampleWavelength())
+		weight = WavelengthWeight(ray.Wavelength())
+	}
+
+	if !m.scatter(ray, rec, attenuation, scattered) {
+		return false
+	}
+
+	*attenuation = attenuation.MultiplyByComponent(weight)
+
+	return true
+}
+
+func (m RoughDielectricMaterial) ScatterSpectral(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	weight := Color{1, 1, 1}
+
+	// Every wavelength would be refracted in a different direction, only the hero one goes on
+	if m.dispersion != nil {
+		ray, weight = ray.KeepHeroWavelength()
+	}
+
+	if !m.scatter(ray, rec, attenuation, scattered) {
+		return false
+	}
+
+	*attenuation = RGBToSpectrum(*attenuation, ray.Wavelengths()).MultiplyByComponent(weight)
+
+	return true
+}
+
+// Scatters a ray, which must carry a single wavelength if the material is dispersive. The attenuation is the RGB
+// absorption of the path inside the material.
+func (m RoughDielectricMaterial) scatter(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	*attenuation = Color{1, 1, 1}
+
+	ir := m.ir
+	if m.dispersion != nil {
+		ir = m.dispersion.IndexOfRefraction(ray.Wavelength())
+	}
+
+	eta := ir // Index of the other side over the index of the side of the ray
+	if !rec.FrontFace {
+		eta = 1 / ir
+
+		// The ray traveled inside the material to get here
+		distance := rec.T * ray.Direction().Length()
+		*attenuation = attenuation.MultiplyByComponent(NewColor(math.Exp(-m.absorption.X*distance), math.Exp(-m.absorption.Y*distance), math.Exp(-m.absorption.Z*distance)))
+	}
+
+	// The normal faces the ray
+	onb := NewOnb(rec.Normal)
+	wo := onb.ToLocal(ray.Direction().UnitVector().Negate())
+
+	if wo.Z <= 0 {
+		return false
+	}
+
+	ggx := NewGgx(m.roughness.Value(rec.U, rec.V, rec.P).X, 0)
+
+	// The microfacet the ray hits either reflects or refracts it, in proportion to its reflectance
+	h := ggx.SampleVisibleNormal(wo)
+	cosTheta := wo.Dot(h)
+
+	var wi Vec3
+
+	if RandomDouble() < FresnelDielectric(cosTheta, eta) {
+		wi = Reflect(wo.Negate(), h)
+
+		if wi.Z <= 0 {
+			return false
+		}
+	} else {
+		wi = Refract(wo.Negate(), h, 1/eta)
+
+		if wi.Z >= 0 {
+			return false
+		}
+	}
+
+	// As the choice between reflection and refraction follows the Fresnel reflectance, only the blocked light is lost
+	*attenuation = attenuation.Mul(ggx.G2(wo, wi) / ggx.G1(wo))
+	*scattered = ray.Bounce(rec.P, onb.ToWorld(wi))
+
+	return true
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Shutter curves describe how much the shutter is open during the exposure, and so which times get more samples
+const (
+	ShutterBox      = iota // The shutter opens and closes instantly
+	ShutterTriangle        // The shutter opens and closes linearly, it's fully open only halfway through the exposure
+	ShutterCustom          // The curve is an arbitrary function
+)
+
+// The camera shutter decides the scene time of every ray
+type Shutter struct {
+	open    float64 // Scene time when the shutter opens...
+	close   float64 // ...and when it closes
+	curve   int
+	cdf     []float64 // Tabulated cumulative distribution of a custom curve
+	readout float64   // Rolling shutter: delay between the exposure of the first and the last scanline
+}
+
+func NewShutter(open, close float64) Shutter {
+	return Shutter{open: open, close: close, curve: ShutterBox}
+}
+
+// Tabulates the cumulative distribution of a custom curve, the curve tells how much the shutter is open (in any unit)
+// at every point x in [0,1] of the exposure
+func newShutterCDF(curve func(x float64) float64) []float64 {
+	const steps = 1024
+
+	cdf := make([]float64, steps+1)
+
+	for i := 1; i <= steps; i++ {
+		x := (float64(i) - 0.5) / steps
+		cdf[i] = cdf[i-1] + math.Max(0, curve(x))
+	}
+
+	if cdf[steps] == 0 {
+		panic("The shutter curve is never open")
+	}
+
+	for i := range cdf {
+		cdf[i] /= cdf[steps]
+	}
+
+	return cdf
+}
+
+// Returns a random point in [0,1] of the exposure, distributed according to the shutter curve
+func (shutter Shutter) sampleExposure() float64 {
+	u := RandomDouble()
+
+	switch shutter.curve {
+	case ShutterTriangle:
+		// Invert the cumulative distribution of the triangle, which is made of two parabolic halves
+		if u < 0.5 {
+			return math.Sqrt(u / 2)
+		}
+		return 1 - math.Sqrt((1-u)/2)
+	case ShutterCustom:
+		// Find the tabulated interval that contains u, then interpolate linearly inside it
+		i := sort.SearchFloat64s(shutter.cdf, u)
+		if i == 0 {
+			return 0
+		}
+		lo, hi := shutter.cdf[i-1], shutter.cdf[i]
+		return (float64(i-1) + (u-lo)/(hi-lo)) / float64(len(shutter.cdf)-1)
+	default:
+		return u
+	}
+}
+
+// Returns a random scene time for a ray of the given scanline (0 is the top scanline), with a rolling shutter
+// the bottom scanlines are exposed later than the top ones
+func (shutter Shutter) sampleTime(scanline, scanlines int) float64 {
+	time := shutter.open + (shutter.close-shutter.open)*shutter.sampleExposure()
+
+	if shutter.readout != 0 && scanlines > 1 {
+		time += shutter.readout * float64(scanline) / float64(scanlines-1)
+	}
+
+	return time
+}
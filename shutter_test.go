@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns the fraction of the exposure samples of a shutter that fall before x
+func exposureFraction(shutter Shutter, x float64) float64 {
+	const samples = 100000
+
+	count := 0
+	for i := 0; i < samples; i++ {
+		if shutter.sampleExposure() < x {
+			count++
+		}
+	}
+
+	return float64(count) / samples
+}
+
+func TestShutterCurves(t *testing.T) {
+	SeedRandom(1)
+
+	box, triangle, custom := NewCamera(), NewCamera(), NewCamera()
+	triangle.SetShutterCurve(ShutterTriangle)
+	custom.SetCustomShutterCurve(func(x float64) float64 { return x })
+
+	tests := []struct {
+		name    string
+		shutter Shutter
+		x, want float64 // The cumulative distribution of the curve at x
+	}{
+		{"box", box.shutter, 0.25, 0.25},
+		{"triangle", triangle.shutter, 0.25, 2 * 0.25 * 0.25},
+		{"triangle", triangle.shutter, 0.5, 0.5},
+		{"custom", custom.shutter, 0.5, 0.25},
+	}
+
+	for _, test := range tests {
+		if got := exposureFraction(test.shutter, test.x); math.Abs(got-test.want) > 0.01 {
+			t.Errorf("%s shutter: %g of the samples are before %g, want %g", test.name, got, test.x, test.want)
+		}
+	}
+}
+
+func TestRollingShutter(t *testing.T) {
+	cam := NewCamera()
+	cam.SetShutter(2, 2)
+	cam.SetRollingShutter(0.5)
+
+	for _, test := range []struct {
+		scanline int
+		want     float64
+	}{{0, 2}, {50, 2.25}, {100, 2.5}} {
+		if got := cam.shutter.sampleTime(test.scanline, 101); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("scanline %d is exposed at %g, want %g", test.scanline, got, test.want)
+		}
+	}
+}
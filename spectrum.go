@@ -0,0 +1,297 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Spectral rendering: some materials (e.g. dispersive glass) behave differently for every wavelength. When a ray hits
+// one of them, it picks a random wavelength and carries only that one from then on, so the path is weighted by the RGB
+// color of the wavelength: averaging many paths, the film gets the RGB color seen by the eye (through the CIE color
+// matching functions). Paths that never meet such materials stay RGB, without any extra noise.
+//
+// In spectral mode instead every camera ray carries three wavelengths (hero wavelength sampling), and the three
+// components of the colors computed along the path are the values at those wavelengths. RGB colors of textures and lights
+// are converted to spectra, materials and lights with spectral data use it, and the film converts every sample to RGB.
+
+// The range of visible wavelengths, in nanometers
+const (
+	WavelengthMin = 380.0
+	WavelengthMax = 780.0
+)
+
+// Piecewise Gaussian function, with different widths on the two sides of the center
+func piecewiseGaussian(x, mu, sigmaLow, sigmaHigh float64) float64 {
+	sigma := sigmaHigh
+	if x < mu {
+		sigma = sigmaLow
+	}
+
+	t := (x - mu) / sigma
+
+	return math.Exp(-t * t / 2)
+}
+
+// The CIE 1931 color matching functions, i.e. how much a wavelength contributes to the X, Y and Z components of a color
+// (multi-lobe fit of Wyman, Sloan and Shirley, "Simple Analytic Approximations to the CIE XYZ Color Matching Functions", 2013)
+func cieXYZ(wavelength float64) Vec3 {
+	x := 1.056*piecewiseGaussian(wavelength, 599.8, 37.9, 31.0) + 0.362*piecewiseGaussian(wavelength, 442.0, 16.0, 26.7) -
+		0.065*piecewiseGaussian(wavelength, 501.1, 20.4, 26.2)
+	y := 0.821*piecewiseGaussian(wavelength, 568.8, 46.9, 40.5) + 0.286*piecewiseGaussian(wavelength, 530.9, 16.3, 31.1)
+	z := 1.217*piecewiseGaussian(wavelength, 437.0, 11.8, 36.0) + 0.681*piecewiseGaussian(wavelength, 459.0, 26.0, 13.8)
+
+	return NewVec3(x, y, z)
+}
+
+// Converts a CIE XYZ color to linear sRGB (D65 white point)
+func XYZToRGB(xyz Vec3) Color {
+	return NewColor(
+		3.2404542*xyz.X-1.5371385*xyz.Y-0.4985314*xyz.Z,
+		-0.9692660*xyz.X+1.8760108*xyz.Y+0.0415560*xyz.Z,
+		0.0556434*xyz.X-0.2040259*xyz.Y+1.0572252*xyz.Z)
+}
+
+// The integral of the RGB color over the visible wavelengths, used to scale the weights so that white light stays white
+var wavelengthIntegral = integrateWavelengthColor()
+
+func integrateWavelengthColor() Color {
+	var sum Color
+
+	for wavelength := WavelengthMin; wavelength < WavelengthMax; wavelength++ {
+		sum = sum.Add(gamutColor(wavelength + 0.5))
+	}
+
+	return sum
+}
+
+// The RGB color of a wavelength. The pure spectral colors are outside of the RGB gamut, so some of their components are
+// negative: they are clamped to 0, otherwise a path could carry negative light.
+func gamutColor(wavelength float64) Color {
+	rgb := XYZToRGB(cieXYZ(wavelength))
+
+	return NewColor(math.Max(0, rgb.X), math.Max(0, rgb.Y), math.Max(0, rgb.Z))
+}
+
+// The RGB color of a unit of light at a wavelength, scaled so that light with the same value at all the wavelengths is white
+func wavelengthColor(wavelength float64) Color {
+	rgb := gamutColor(wavelength)
+
+	return NewColor(rgb.X/wavelengthIntegral.X, rgb.Y/wavelengthIntegral.Y, rgb.Z/wavelengthIntegral.Z)
+}
+
+// Wavelengths are sampled with a density proportional to sech²(k(λ - center)), which follows the sensitivity of the eye
+// (Radziszewski, Boryczko and Alda, "An Improved Technique for Full Spectral Rendering", 2009)
+const (
+	wavelengthCenter    = 538.0
+	wavelengthSharpness = 0.0072
+)
+
+// Returns a random visible wavelength, those the eye is more sensitive to are more likely
+func SampleWavelength() float64 {
+	a := math.Tanh(wavelengthSharpness * (WavelengthMin - wavelengthCenter))
+	b := math.Tanh(wavelengthSharpness * (WavelengthMax - wavelengthCenter))
+
+	return wavelengthCenter + math.Atanh(a+(b-a)*RandomDouble())/wavelengthSharpness
+}
+
+// The probability density of SampleWavelength
+func wavelengthPdf(wavelength float64) float64 {
+	a := math.Tanh(wavelengthSharpness * (WavelengthMin - wavelengthCenter))
+	b := math.Tanh(wavelengthSharpness * (WavelengthMax - wavelengthCenter))
+	c := math.Cosh(wavelengthSharpness * (wavelength - wavelengthCenter))
+
+	return wavelengthSharpness / ((b - a) * c * c)
+}
+
+// Returns the weight of a path that carries a single wavelength sampled by SampleWavelength: the RGB color of the wavelength
+// divided by its probability, scaled so that the average over all the wavelengths is white. The components are never negative.
+func WavelengthWeight(wavelength float64) Color {
+	return wavelengthColor(wavelength).Div(wavelengthPdf(wavelength))
+}
+
+// The sum of the probability densities of the three wavelengths of a spectral ray, as any of them could have been the hero
+func wavelengthsPdf(wavelengths Vec3) float64 {
+	return wavelengthPdf(wavelengths.X) + wavelengthPdf(wavelengths.Y) + wavelengthPdf(wavelengths.Z)
+}
+
+// Converts the color computed along a camera ray to the RGB color of the film
+func filmColor(ray Ray, c Color) Color {
+	if !ray.IsSpectral() {
+		return c
+	}
+
+	wavelengths := ray.Wavelengths()
+
+	rgb := wavelengthColor(wavelengths.X).Mul(c.X).Add(wavelengthColor(wavelengths.Y).Mul(c.Y)).Add(wavelengthColor(wavelengths.Z).Mul(c.Z))
+	rgb = rgb.Div(wavelengthsPdf(wavelengths))
+
+	// The film must never get negative light
+	return NewColor(math.Max(0, rgb.X), math.Max(0, rgb.Y), math.Max(0, rgb.Z))
+}
+
+// A spectrum gives a value (e.g. reflectance or emitted power) for every wavelength in nanometers
+type Spectrum interface {
+	At(wavelength float64) float64
+}
+
+// Returns the RGB color of a spectrum
+func SpectrumToRGB(s Spectrum) Color {
+	var sum Color
+
+	for wavelength := WavelengthMin; wavelength < WavelengthMax; wavelength++ {
+		sum = sum.Add(wavelengthColor(wavelength + 0.5).Mul(s.At(wavelength + 0.5)))
+	}
+
+	return sum
+}
+
+// A spectrum known at some wavelengths (e.g. measured), linearly interpolated between them and constant outside of them
+type SampledSpectrum struct {
+	wavelengths []float64
+	values      []float64
+}
+
+// The wavelengths must be in increasing order
+func NewSampledSpectrum(wavelengths, values []float64) SampledSpectrum {
+	if len(wavelengths) == 0 || len(wavelengths) != len(values) {
+		panic("A sampled spectrum needs one value for every wavelength")
+	}
+
+	return SampledSpectrum{wavelengths: wavelengths, values: values}
+}
+
+func (ss SampledSpectrum) At(wavelength float64) float64 {
+	i := sort.SearchFloat64s(ss.wavelengths, wavelength)
+
+	if i == 0 {
+		return ss.values[0]
+	}
+	if i == len(ss.wavelengths) {
+		return ss.values[i-1]
+	}
+
+	t := (wavelength - ss.wavelengths[i-1]) / (ss.wavelengths[i] - ss.wavelengths[i-1])
+
+	return ss.values[i-1] + t*(ss.values[i]-ss.values[i-1])
+}
+
+// The light emitted by a black body at the given temperature in kelvin (e.g. 2700 for a light bulb, 5800 for the sun),
+// scaled so that its peak is 1
+type BlackbodySpectrum struct {
+	temperature float64
+}
+
+func NewBlackbodySpectrum(temperature float64) BlackbodySpectrum {
+	return BlackbodySpectrum{temperature: temperature}
+}
+
+// Planck's law, from a wavelength in nanometers
+func planck(wavelength, temperature float64) float64 {
+	const (
+		c  = 299792458.0    // Speed of light
+		h  = 6.62606957e-34 // Planck's constant
+		kb = 1.3806488e-23  // Boltzmann's constant
+	)
+
+	l := wavelength * 1e-9
+
+	return 2 * h * c * c / (math.Pow(l, 5) * (math.Exp(h*c/(l*kb*temperature)) - 1))
+}
+
+func (bs BlackbodySpectrum) At(wavelength float64) float64 {
+	peak := 2.8977721e6 / bs.temperature // Wien's displacement law
+
+	return planck(wavelength, bs.temperature) / planck(peak, bs.temperature)
+}
+
+// RGB colors are converted to spectra as combinations of three smooth bands (blue, green and red) that add up to 1,
+// so white is the same at all wavelengths. The coefficients of the bands are chosen so that the spectrum has the RGB
+// color it comes from, unless it would have negative values (very saturated colors).
+func spectralBands(wavelength float64) Vec3 {
+	blue := 1 / (1 + math.Exp((wavelength-490)/10))
+	red := 1 / (1 + math.Exp((590-wavelength)/10))
+
+	return NewVec3(red, 1-red-blue, blue)
+}
+
+// The rows of the matrix that gives the coefficients of the bands for an RGB color
+var rgbToBands = bandsMatrix()
+
+func bandsMatrix() [3]Vec3 {
+	// The columns are the RGB colors of the three bands
+	var columns [3]Vec3
+
+	for wavelength := WavelengthMin; wavelength < WavelengthMax; wavelength++ {
+		bands := spectralBands(wavelength + 0.5)
+		rgb := wavelengthColor(wavelength + 0.5)
+
+		columns[0] = columns[0].Add(rgb.Mul(bands.X))
+		columns[1] = columns[1].Add(rgb.Mul(bands.Y))
+		columns[2] = columns[2].Add(rgb.Mul(bands.Z))
+	}
+
+	// The inverse of a 3x3 matrix
+	det := columns[0].Dot(columns[1].Cross(columns[2]))
+
+	return [3]Vec3{columns[1].Cross(columns[2]).Div(det), columns[2].Cross(columns[0]).Div(det), columns[0].Cross(columns[1]).Div(det)}
+}
+
+// Converts an RGB color (e.g. of a texture) to a spectrum, and returns its values at the given three wavelengths
+func RGBToSpectrum(c Color, wavelengths Vec3) Color {
+	coefficients := NewVec3(rgbToBands[0].Dot(c), rgbToBands[1].Dot(c), rgbToBands[2].Dot(c))
+	value := func(wavelength float64) float64 {
+		return math.Max(0, coefficients.Dot(spectralBands(wavelength)))
+	}
+
+	return NewColor(value(wavelengths.X), value(wavelengths.Y), value(wavelengths.Z))
+}
+
+// Materials that compute the attenuation of spectral rays themselves (e.g. from spectral data), instead of
+// converting the RGB attenuation of Scatter
+type SpectralScatterer interface {
+	// Same as Scatter, but the components of the attenuation are the values at the wavelengths of the spectral ray
+	ScatterSpectral(ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool
+}
+
+// Materials that emit light with a spectrum, instead of an RGB color
+type SpectralEmitter interface {
+	// Returns the emitted light at the three given wavelengths
+	EmittedSpectral(u, v float64, p Point3, wavelengths Vec3) Color
+}
+
+// Scatters a ray on a material, converting the attenuation of RGB materials for spectral rays
+func scatter(mat Material, ray Ray, rec *HitRecord, attenuation *Color, scattered *Ray) bool {
+	if !ray.IsSpectral() {
+		return mat.Scatter(ray, rec, attenuation, scattered)
+	}
+
+	if ss, ok := mat.(SpectralScatterer); ok {
+		return ss.ScatterSpectral(ray, rec, attenuation, scattered)
+	}
+
+	if !mat.Scatter(ray, rec, attenuation, scattered) {
+		return false
+	}
+
+	*attenuation = RGBToSpectrum(*attenuation, ray.Wavelengths())
+
+	return true
+}
+
+// Returns the light emitted by a material, converting the color of RGB materials for spectral rays
+func emitted(mat Material, ray Ray, rec *HitRecord) Color {
+	if !ray.IsSpectral() {
+		return mat.Emitted(rec.U, rec.V, rec.P)
+	}
+
+	return emittedSpectral(mat, rec.U, rec.V, rec.P, ray.Wavelengths())
+}
+
+// Returns the light emitted by a material at the given three wavelengths
+func emittedSpectral(mat Material, u, v float64, p Point3, wavelengths Vec3) Color {
+	if se, ok := mat.(SpectralEmitter); ok {
+		return se.EmittedSpectral(u, v, p, wavelengths)
+	}
+
+	return RGBToSpectrum(mat.Emitted(u, v, p), wavelengths)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWavelengthWeight(t *testing.T) {
+	SeedRandom(1)
+
+	for wavelength := WavelengthMin; wavelength <= WavelengthMax; wavelength++ {
+		if w := WavelengthWeight(wavelength); w.X < 0 || w.Y < 0 || w.Z < 0 {
+			t.Fatalf("the weight of %g nm is %v, want no negative components", wavelength, w)
+		}
+	}
+
+	// White light stays white
+	const samples = 200000
+
+	sum := Color{}
+	for i := 0; i < samples; i++ {
+		sum = sum.Add(WavelengthWeight(SampleWavelength()))
+	}
+
+	if average := sum.Div(samples); !nearVec3(average, NewColor(1, 1, 1), 0.01) {
+		t.Errorf("the average weight of the wavelengths is %v, want white", average)
+	}
+}
+
+func TestFilmColor(t *testing.T) {
+	SeedRandom(1)
+
+	ray := NewRay(NewPoint3(0, 0, 0), NewVec3(0, 0, -1), 0)
+
+	for i := 0; i < 10000; i++ {
+		ray = ray.WithHeroWavelength(SampleWavelength())
+
+		// Light at a single wavelength, the one most outside of the RGB gamut
+		if c := filmColor(ray, NewColor(1, 0, 0)); c.X < 0 || c.Y < 0 || c.Z < 0 {
+			t.Fatalf("the wavelengths %v give the film color %v, want no negative components", ray.Wavelengths(), c)
+		}
+	}
+
+	for _, linear := range []float64{-1, math.NaN(), math.Inf(-1)} {
+		if c := LinearToRGB(linear); c != 0 {
+			t.Errorf("the linear component %g is %d, want 0", linear, c)
+		}
+	}
+}
+
+func TestSpectralSceneIdentity(t *testing.T) {
+	for _, spectral := range []bool{false, true} {
+		cam, _, err := buildScene(SceneIdentity{ImageNo: 36, Seed: 1, Spectral: spectral}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cam.spectral != spectral {
+			t.Errorf("the scene with spectral %v has a camera with spectral %v", spectral, cam.spectral)
+		}
+	}
+}
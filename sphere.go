@@ -6,8 +6,10 @@ type Sphere struct {
 	center    Point3
 	radius    float64
 	mat       Material
-	centerVec Vec3 // Movement vector
-	bbox      Aabb // Bounding box
+	centerVec Vec3    // Movement vector
+	startTime float64 // Scene time when the sphere is at center...
+	duration  float64 // ...and how long it takes to move by centerVec
+	bbox      Aabb    // Bounding box
 }
 
 // Stationary sphere
@@ -16,11 +18,17 @@ func NewSphere(center Point3, radius float64, mat Material) Sphere {
 	return Sphere{center: center, radius: radius, mat: mat, bbox: NewAabb(center.Sub(rvec), center.Add(rvec))}
 }
 
+// Sphere moving from startCenter (time=0) to endCenter (time=1)
 func NewMovingSphere(startCenter, endCenter Point3, radius float64, mat Material) Sphere {
+	return NewMovingSphereInTime(startCenter, 0, endCenter, 1, radius, mat)
+}
+
+// Sphere moving from startCenter to endCenter in the given scene times, it stays still before and after
+func NewMovingSphereInTime(startCenter Point3, startTime float64, endCenter Point3, endTime float64, radius float64, mat Material) Sphere {
 	rvec := NewVec3(radius, radius, radius)
 	bbox1 := NewAabb(startCenter.Sub(rvec), startCenter.Add(rvec))
 	bbox2 := NewAabb(endCenter.Sub(rvec), endCenter.Add(rvec))
-	return Sphere{center: startCenter, radius: radius, mat: mat, centerVec: endCenter.Sub(startCenter), bbox: bbox1.Union(bbox2)}
+	return Sphere{center: startCenter, radius: radius, mat: mat, centerVec: endCenter.Sub(startCenter), startTime: startTime, duration: endTime - startTime, bbox: bbox1.Union(bbox2)}
 }
 
 // Returns the (u,v) coordinates of a point on a unit sphere centered at the origin, where:
@@ -38,8 +46,11 @@ func getSphereUV(p Point3) (float64, float64) {
 
 // Implement the Hittable interface
 func (s Sphere) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
-	// Linearly interpolate from startCenter (time=0) to endCenter (time=1)
-	center := s.center.Add(s.centerVec.Mul(ray.Time()))
+	// Linearly interpolate from startCenter to endCenter
+	center := s.center
+	if s.duration != 0 {
+		center = center.Add(s.centerVec.Mul(animationFraction(ray.Time(), s.startTime, s.duration)))
+	}
 
 	oc := ray.Origin().Sub(center)
 	a := ray.Direction().Dot(ray.Direction())
@@ -67,6 +78,7 @@ func (s Sphere) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
 	rec.SetFaceNormal(ray, outwardNormal)
 	rec.Mat = s.mat
 	rec.U, rec.V = getSphereUV(outwardNormal)
+	rec.Tangent = NewVec3(outwardNormal.Z, 0, -outwardNormal.X) // U goes around the Y axis, it's zero at the poles
 
 	return true
 }
@@ -0,0 +1,49 @@
+package main
+
+// Layouts of the images of a stereo camera, the left eye is on the left or on the top
+const (
+	StereoNone       = iota
+	StereoSideBySide // The image is twice as wide as the image of one eye
+	StereoTopBottom  // The image is twice as high as the image of one eye
+)
+
+// Maps a pixel of the image to the pixel of the image of one eye, the eye is -1 for the left eye, +1 for the right eye
+// and 0 if the camera is not stereo
+func (camera Camera) eyePixel(i, j int) (int, int, float64) {
+	switch camera.stereo {
+	case StereoSideBySide:
+		if i >= camera.eyeWidth {
+			return i - camera.eyeWidth, j, +1
+		}
+		return i, j, -1
+	case StereoTopBottom:
+		if j >= camera.eyeHeight {
+			return i, j - camera.eyeHeight, +1
+		}
+		return i, j, -1
+	}
+
+	return i, j, 0
+}
+
+// Returns the offset of the center of an eye from the center of the camera
+func (camera Camera) eyeOffset(eye float64) Vec3 {
+	return camera.u.Mul(eye * camera.interocular / 2)
+}
+
+// Omni-directional stereo: the eyes turn around the camera center together with the view direction, so that every
+// direction of the panorama is seen with the correct parallax. The offset shrinks towards the poles (where there is no
+// horizontal direction to turn to) which avoids swapping the eyes when looking straight up or down.
+func (camera Camera) odsOffset(direction Vec3, eye float64) Vec3 {
+	return direction.Cross(camera.v).Mul(eye * camera.interocular / 2)
+}
+
+// Returns the center of an eye and the point of the viewport the eye sees for the pixel sample of the camera center.
+// The two eyes look through the same window on the convergence plane (off-axis stereo), objects at the convergence
+// distance appear at the depth of the screen, nearer objects in front of it and farther objects behind it.
+func (camera Camera) stereoViewport(pixelSample Point3, eye float64) (Point3, Point3) {
+	center := camera.lookFrom.Add(camera.eyeOffset(eye))
+	converged := camera.lookFrom.Add(pixelSample.Sub(camera.lookFrom).Mul(camera.convergePlane / camera.focusPlane))
+
+	return center, center.Add(converged.Sub(center).Mul(camera.focusPlane / camera.convergePlane))
+}
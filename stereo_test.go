@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEyePixel(t *testing.T) {
+	sideBySide, topBottom, mono := NewCamera(), NewCamera(), NewCamera()
+	sideBySide.SetStereo(StereoSideBySide, 0.064)
+	topBottom.SetStereo(StereoTopBottom, 0.064)
+
+	for _, cam := range []*Camera{&sideBySide, &topBottom, &mono} {
+		cam.SetImageWidth(200)
+		cam.SetAspectRatio(2)
+		cam.Initialize()
+	}
+
+	tests := []struct {
+		name         string
+		cam          Camera
+		i, j         int
+		wantI, wantJ int
+		wantEye      float64
+	}{
+		{"side by side, left", sideBySide, 99, 49, 99, 49, -1},
+		{"side by side, right", sideBySide, 100, 10, 0, 10, +1},
+		{"top bottom, left", topBottom, 150, 99, 150, 99, -1},
+		{"top bottom, right", topBottom, 150, 100, 150, 0, +1},
+		{"mono", mono, 150, 80, 150, 80, 0},
+	}
+
+	for _, test := range tests {
+		if i, j, eye := test.cam.eyePixel(test.i, test.j); i != test.wantI || j != test.wantJ || eye != test.wantEye {
+			t.Errorf("%s: pixel %d, %d is %d, %d of eye %g, want %d, %d of eye %g",
+				test.name, test.i, test.j, i, j, eye, test.wantI, test.wantJ, test.wantEye)
+		}
+	}
+}
+
+// Returns where a ray crosses the plane at the given distance in front of the camera
+func crossPlane(cam Camera, ray Ray, distance float64) Point3 {
+	depth := func(p Point3) float64 { return cam.lookFrom.Sub(p).Dot(cam.w) }
+
+	t := (distance - depth(ray.Origin())) / ray.Direction().Negate().Dot(cam.w)
+
+	return ray.At(t)
+}
+
+func TestStereoConvergence(t *testing.T) {
+	cam := NewCamera()
+	cam.SetImageWidth(200)
+	cam.SetAspectRatio(1)
+	cam.SetLookFrom(NewPoint3(0, 0, 0))
+	cam.SetLookAt(NewPoint3(0, 0, -10))
+	cam.SetStereo(StereoSideBySide, 0.5)
+	cam.SetConvergenceDistance(4)
+	cam.Initialize()
+
+	for _, pixel := range [][2]int{{0, 0}, {50, 50}, {99, 20}} {
+		// The same sample of the same pixel of the two eyes
+		SeedRandom(1)
+		left, _ := cam.getRay(pixel[0], pixel[1])
+		SeedRandom(1)
+		right, _ := cam.getRay(pixel[0]+cam.eyeWidth, pixel[1])
+
+		if distance := left.Origin().Sub(right.Origin()).Length(); math.Abs(distance-0.5) > 1e-9 {
+			t.Errorf("pixel %v: the eyes are at %v and %v, want them 0.5 apart", pixel, left.Origin(), right.Origin())
+		}
+
+		// A point on the convergence plane is seen by the same pixel of both eyes
+		if l, r := crossPlane(cam, left, 4), crossPlane(cam, right, 4); !nearVec3(l, r, 1e-9) {
+			t.Errorf("pixel %v: the eyes see %v and %v on the convergence plane, want the same point", pixel, l, r)
+		}
+
+		// Elsewhere they see different points
+		if l, r := crossPlane(cam, left, 8), crossPlane(cam, right, 8); nearVec3(l, r, 1e-3) {
+			t.Errorf("pixel %v: the eyes see %v on the plane at distance 8, want different points", pixel, l)
+		}
+	}
+}
+
+func TestOdsOffset(t *testing.T) {
+	cam := NewCamera()
+	cam.SetProjection(ProjectionEquirectangular)
+	cam.SetStereo(StereoTopBottom, 0.064)
+	cam.Initialize()
+
+	for _, direction := range []Vec3{NewVec3(0, 0, -1), NewVec3(1, 0, 0), NewVec3(0, 0.6, 0.8)} {
+		offset := cam.odsOffset(direction, -1)
+
+		// The eye is at the side of the direction, at half the interocular distance on the horizon
+		if math.Abs(offset.Dot(direction)) > 1e-12 {
+			t.Errorf("direction %v: the left eye is at %v, want it perpendicular to the direction", direction, offset)
+		}
+		if want := 0.032 * math.Sqrt(1-direction.Y*direction.Y); math.Abs(offset.Length()-want) > 1e-12 {
+			t.Errorf("direction %v: the left eye is %g from the center, want %g", direction, offset.Length(), want)
+		}
+		if left := direction.Cross(offset); left.Y < 0 {
+			t.Errorf("direction %v: the left eye is at %v, on the right", direction, offset)
+		}
+	}
+
+	// Looking straight up the eyes meet
+	if offset := cam.odsOffset(NewVec3(0, 1, 0), 1); offset.Length() > 1e-12 {
+		t.Errorf("the right eye looking up is at %v, want the camera center", offset)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
@@ -75,43 +76,54 @@ func (ct CheckerTexture) Value(u, v float64, p Point3) Color {
 	}
 }
 
-// Creates an image texture from a PNG or JPEG file
+// Creates an image texture from a PNG or JPEG file, panics if the file can't be loaded
 func NewImageTexture(filename string) ImageTexture {
+	t, err := LoadImageTexture(filename)
+
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return t
+}
+
+// Loads an image texture from a PNG or JPEG file
+func LoadImageTexture(filename string) (ImageTexture, error) {
 	f, err := os.Open(filename)
 
-	if err == nil {
-		defer f.Close()
+	if err != nil {
+		return ImageTexture{}, fmt.Errorf("cannot load file %s: %w", filename, err)
+	}
+
+	defer f.Close()
 
-		image, _, err := image.Decode(f)
+	image, _, err := image.Decode(f)
 
-		if err == nil {
-			bounds := image.Bounds()
+	if err != nil {
+		return ImageTexture{}, fmt.Errorf("cannot decode image %s: %w", filename, err)
+	}
 
-			w := bounds.Max.X - bounds.Min.X
-			h := bounds.Max.Y - bounds.Min.Y
+	bounds := image.Bounds()
 
-			t := ImageTexture{data: make([]Color, w*h), width: w, height: h}
+	w := bounds.Max.X - bounds.Min.X
+	h := bounds.Max.Y - bounds.Min.Y
 
-			// Convert the image pixels to our internal color format
-			for y := 0; y < h; y++ {
-				for x := 0; x < w; x++ {
-					r, g, b, _ := image.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+	t := ImageTexture{data: make([]Color, w*h), width: w, height: h}
 
-					o := x + y*w // Offset into our data array
+	// Convert the image pixels to our internal color format
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := image.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
 
-					t.data[o].X = float64(r) / 0xffff
-					t.data[o].Y = float64(g) / 0xffff
-					t.data[o].Z = float64(b) / 0xffff
-				}
-			}
+			o := x + y*w // Offset into our data array
 
-			return t
-		} else {
-			panic("Cannot decode image: " + filename)
+			t.data[o].X = float64(r) / 0xffff
+			t.data[o].Y = float64(g) / 0xffff
+			t.data[o].Z = float64(b) / 0xffff
 		}
-	} else {
-		panic("Cannot load file: " + filename)
 	}
+
+	return t, nil
 }
 
 func (it ImageTexture) Value(u, v float64, p Point3) Color {
@@ -167,3 +179,49 @@ func NewMarbleTexture(scale float64) NoiseTexture {
 	// To get the marble effect right, turbulence should use the unscaled point, that's why the 1/scale factor
 	return NewNoiseTextureWithGenerator(scale, NewTurbulenceNoiseWithPhase(10, 1/scale, 7))
 }
+
+// A texture multiplied by a color, component by component (e.g. a color factor applied to an image)
+type ScaledTexture struct {
+	texture Texture
+	scale   Color
+}
+
+func NewScaledTexture(texture Texture, scale Color) ScaledTexture {
+	return ScaledTexture{texture: texture, scale: scale}
+}
+
+func (st ScaledTexture) Value(u, v float64, p Point3) Color {
+	return st.texture.Value(u, v, p).MultiplyByComponent(st.scale)
+}
+
+// One component of a texture (0 is red, 1 green, 2 blue), in all the components (e.g. a map that packs grayscale values)
+type ChannelTexture struct {
+	texture Texture
+	channel int
+}
+
+func NewChannelTexture(texture Texture, channel int) ChannelTexture {
+	return ChannelTexture{texture: texture, channel: channel}
+}
+
+func (ct ChannelTexture) Value(u, v float64, p Point3) Color {
+	c := ct.texture.Value(u, v, p)
+	value := [3]float64{c.X, c.Y, c.Z}[ct.channel]
+
+	return Color{value, value, value}
+}
+
+// A texture that holds sRGB colors (e.g. the colors of an image file), converted to linear colors
+type SRGBTexture struct {
+	texture Texture
+}
+
+func NewSRGBTexture(texture Texture) SRGBTexture {
+	return SRGBTexture{texture: texture}
+}
+
+func (st SRGBTexture) Value(u, v float64, p Point3) Color {
+	c := st.texture.Value(u, v, p)
+
+	return Color{SRGBToLinear(c.X), SRGBToLinear(c.Y), SRGBToLinear(c.Z)}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Pixel tracing: follows the samples of a single pixel and writes what happens at every bounce, to debug a noisy spot
+
+// Returns a short description of an object, with the objects it contains: e.g. Translate(RotateY(HittableList[6]))
+func describeHittable(object Hittable) string {
+	switch h := object.(type) {
+	case HittableList:
+		return fmt.Sprintf("HittableList[%d]", len(h.objects))
+	case BvhNode:
+		return "BvhNode"
+	}
+
+	name := typeName(object)
+
+	if children := hittableChildren(object); len(children) == 1 {
+		return name + "(" + describeHittable(children[0]) + ")"
+	}
+
+	return name
+}
+
+// Returns the name of the type of a value, without the package name
+func typeName(value any) string {
+	name := fmt.Sprintf("%T", value)
+	return strings.TrimPrefix(name, "main.")
+}
+
+// Returns the object of a list or of a BVH that the ray hits at distance t, or the list itself if it can't be found
+// (e.g. a volume, whose hits are random)
+func findHitObject(object Hittable, ray Ray, t float64) Hittable {
+	switch object.(type) {
+	case HittableList, BvhNode:
+		const epsilon = 1e-9
+
+		for _, child := range hittableChildren(object) {
+			rec := HitRecord{}
+
+			if child.Hit(ray, t-epsilon, t+epsilon, &rec) {
+				return findHitObject(child, ray, t)
+			}
+		}
+	}
+
+	return object
+}
+
+// Writes every bounce of the given number of samples of the pixel at location i, j
+func (camera *Camera) TracePixel(w io.Writer, world Hittable, i, j, samples int) {
+	var sum Color
+
+	for sample := 0; sample < samples; sample++ {
+		fmt.Fprintf(w, "Pixel %d,%d sample %d\n", i, j, sample)
+
+		ray, ok := camera.getRay(i, j)
+
+		if !ok {
+			fmt.Fprintln(w, "  No ray, the pixel is outside the projection or the lens blocks the ray")
+			continue
+		}
+
+		c := filmColor(ray, camera.traceRay(w, ray, world, camera.maxRayDepth))
+		sum = sum.Add(c)
+
+		fmt.Fprintf(w, "  Sample color %v\n", c)
+	}
+
+	fmt.Fprintf(w, "Pixel %d,%d average color %v\n", i, j, sum.Div(float64(samples)))
+}
+
+// Same as RayColor, but writes every bounce
+func (camera *Camera) traceRay(w io.Writer, ray Ray, world Hittable, depth int) Color {
+	bounce := camera.maxRayDepth - depth
+
+	if depth <= 0 {
+		fmt.Fprintf(w, "  Bounce %d: maximum depth reached\n", bounce)
+		return Color{0, 0, 0}
+	}
+
+	fmt.Fprintf(w, "  Bounce %d: ray origin %v direction %v time %g\n", bounce, ray.Origin(), ray.Direction(), ray.Time())
+
+	if ray.IsSpectral() {
+		fmt.Fprintf(w, "    Wavelengths %.1f nm (hero only %t)\n", ray.Wavelengths(), ray.heroOnly)
+	} else if ray.Wavelength() != 0 {
+		fmt.Fprintf(w, "    Wavelength %.1f nm\n", ray.Wavelength())
+	}
+
+	rec := HitRecord{}
+
+	if !world.Hit(ray, 0.001, math.Inf(+1), &rec) {
+		fmt.Fprintf(w, "    Missed, background %v\n", camera.backgroundColor(ray))
+		return camera.backgroundColor(ray)
+	}
+
+	fmt.Fprintf(w, "    Hit %s, material %s\n", describeHittable(findHitObject(world, ray, rec.T)), typeName(rec.Mat))
+	fmt.Fprintf(w, "    T %g P %v Normal %v FrontFace %t U %g V %g\n", rec.T, rec.P, rec.Normal, rec.FrontFace, rec.U, rec.V)
+
+	scattered := Ray{}
+	attenuation := Color{}
+	color := emitted(rec.Mat, ray, &rec)
+
+	fmt.Fprintf(w, "    Emitted %v\n", color)
+
+	if scatter(rec.Mat, ray, &rec, &attenuation, &scattered) {
+		fmt.Fprintf(w, "    Scattered, attenuation %v\n", attenuation)
+
+		c := camera.traceRay(w, scattered, world, depth-1)
+		color = color.Add(c.MultiplyByComponent(attenuation))
+	} else {
+		fmt.Fprintln(w, "    Absorbed")
+	}
+
+	return color
+}
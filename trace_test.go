@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTracePixel(t *testing.T) {
+	world := NewHittableList()
+	world.Add(NewSphere(NewPoint3(0, 0, -2), 1, NewLambertianMaterial(NewColor(0.5, 0.5, 0.5))))
+
+	cam := NewCamera()
+	cam.SetImageWidth(40)
+	cam.SetAspectRatio(2)
+	cam.Initialize()
+
+	// The trace follows the same paths as the render
+	SeedRandom(1)
+	want := cam.SampleColor(world, 20, 10)
+
+	SeedRandom(1)
+	var b strings.Builder
+	cam.TracePixel(&b, world, 20, 10, 2)
+	trace := b.String()
+
+	for _, line := range []string{
+		"Pixel 20,10 sample 0\n",
+		"Pixel 20,10 sample 1\n",
+		"  Bounce 0: ray origin",
+		"    Hit Sphere, material TextureLambertianMaterial\n",
+		"    Scattered, attenuation {0.5 0.5 0.5}\n",
+		"  Bounce 1: ray origin",
+		fmt.Sprintf("  Sample color %v\n", want),
+		"Pixel 20,10 average color",
+	} {
+		if !strings.Contains(trace, line) {
+			t.Errorf("the trace doesn't contain %q:\n%s", line, trace)
+		}
+	}
+}
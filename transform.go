@@ -45,18 +45,13 @@ func NewRotateY(object Hittable, angleInDegrees float64) RotateY {
 	sinTheta := math.Sin(theta)
 	cosTheta := math.Cos(theta)
 
-	// We need to build a new bounding taking into account all rotations
-	rotate := func(p Point3) Point3 {
+	// We need to build a new bounding box taking into account all rotations: rotating just the Min and Max corners is not enough,
+	// as the other corners of the original box may end up farther away
+	bbox := object.BoundingBox().TransformFunc(func(p Point3) Point3 {
 		return NewPoint3(cosTheta*p.X+sinTheta*p.Z, p.Y, -sinTheta*p.X+cosTheta*p.Z)
-	}
-
-	p1 := rotate(object.BoundingBox().Min)
-	p2 := rotate(object.BoundingBox().Max)
+	})
 
-	min := NewPoint3(math.Min(p1.X, p2.X), p1.Y, math.Min(p1.Z, p2.Z))
-	max := NewPoint3(math.Max(p1.X, p2.X), p2.Y, math.Max(p1.Z, p2.Z))
-
-	return RotateY{object, sinTheta, cosTheta, NewAabb(min, max)}
+	return RotateY{object, sinTheta, cosTheta, bbox}
 }
 
 func (roty RotateY) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
@@ -75,6 +70,7 @@ func (roty RotateY) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool
 
 	// Change the normal from object space to world space
 	rec.Normal = NewVec3(roty.cosTheta*rec.Normal.X+roty.sinTheta*rec.Normal.Z, rec.Normal.Y, -roty.sinTheta*rec.Normal.X+roty.cosTheta*rec.Normal.Z)
+	rec.Tangent = NewVec3(roty.cosTheta*rec.Tangent.X+roty.sinTheta*rec.Tangent.Z, rec.Tangent.Y, -roty.sinTheta*rec.Tangent.X+roty.cosTheta*rec.Tangent.Z)
 
 	return true
 }
@@ -82,3 +78,244 @@ func (roty RotateY) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool
 func (roty RotateY) BoundingBox() Aabb {
 	return roty.bbox
 }
+
+// An instance of a Hittable object that is transformed by an arbitrary affine transform, see Mat4
+type Transform struct {
+	object   Hittable // The original object is stored as-is, all the magic happens in the Hit() function
+	toWorld  Mat4
+	toObject Mat4
+	flat     bool // The transform is singular, it flattens the object to nothing
+	bbox     Aabb
+}
+
+// Places the object with the transform m, if m is singular (e.g. a scale is 0) the object is flattened and never hit
+func NewTransform(object Hittable, m Mat4) Transform {
+	toObject, ok := m.Inverse()
+
+	return Transform{object, m, toObject, !ok, object.BoundingBox().Transform(m)}
+}
+
+func (tr Transform) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
+	if tr.flat {
+		return false
+	}
+
+	return hitTransformed(tr.object, tr.toWorld, tr.toObject, ray, rayTmin, rayTmax, rec)
+}
+
+func (tr Transform) BoundingBox() Aabb {
+	return tr.bbox
+}
+
+// Hits an object placed in the world by the transform toWorld, whose inverse is toObject
+func hitTransformed(object Hittable, toWorld, toObject Mat4, ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
+	// Change the ray from world space to object space, the direction is not normalized so t is the same in both spaces
+	objectRay := NewRay(toObject.TransformPoint(ray.Origin()), toObject.TransformVector(ray.Direction()), ray.Time())
+
+	// Determine where (if any) an intersection occurs in object space
+	if !object.Hit(objectRay, rayTmin, rayTmax, rec) {
+		return false
+	}
+
+	// Change the intersection point from object space to world space
+	rec.P = toWorld.TransformPoint(rec.P)
+
+	// Change the normal from object space to world space, this keeps the normal on the same side as before with respect to the ray
+	rec.Normal = toObject.TransformNormal(rec.Normal).UnitVector()
+
+	// The tangent lies on the surface, so it's transformed like the points
+	rec.Tangent = toWorld.TransformVector(rec.Tangent)
+
+	return true
+}
+
+// A transform made of a scale, followed by a rotation and then by a translation. Unlike a matrix, it can be interpolated.
+type TRS struct {
+	Translation Vec3
+	Rotation    Quaternion
+	Scale       Vec3
+}
+
+func NewTRS(translation Vec3, rotation Quaternion, scale Vec3) TRS {
+	return TRS{translation, rotation, scale}
+}
+
+// Decomposes a matrix made of scales, rotations and translations (but no shear) into a TRS
+func NewTRSFromMat4(m Mat4) TRS {
+	columns := [3]Vec3{}
+	scale := [3]float64{}
+
+	for i := range columns {
+		columns[i] = NewVec3(m[0][i], m[1][i], m[2][i])
+		scale[i] = columns[i].Length()
+	}
+
+	// A mirroring transform has a negative determinant, move the mirroring into the scale so that the rest is a rotation
+	if columns[0].Cross(columns[1]).Dot(columns[2]) < 0 {
+		scale[0] = -scale[0]
+	}
+
+	rotation := IdentityMat4()
+	for i := range columns {
+		c := columns[i].Div(scale[i])
+		rotation[0][i], rotation[1][i], rotation[2][i] = c.X, c.Y, c.Z
+	}
+
+	return TRS{NewVec3(m[0][3], m[1][3], m[2][3]), NewQuaternionFromMat4(rotation), NewVec3(scale[0], scale[1], scale[2])}
+}
+
+func (trs TRS) Mat4() Mat4 {
+	return NewScaling(trs.Scale).Then(trs.Rotation.Mat4()).Then(NewTranslation(trs.Translation))
+}
+
+// Returns the inverse of the transform, or false if it's degenerate (a scale is 0)
+func (trs TRS) Inverse() (Mat4, bool) {
+	if trs.Scale.X == 0 || trs.Scale.Y == 0 || trs.Scale.Z == 0 {
+		return Mat4{}, false
+	}
+
+	// The inverse of a unit quaternion is its conjugate
+	rotation := Quaternion{W: trs.Rotation.W, X: -trs.Rotation.X, Y: -trs.Rotation.Y, Z: -trs.Rotation.Z}
+	scale := NewVec3(1/trs.Scale.X, 1/trs.Scale.Y, 1/trs.Scale.Z)
+
+	return NewTranslation(trs.Translation.Negate()).Then(rotation.Mat4()).Then(NewScaling(scale)), true
+}
+
+// Interpolates from trs (t=0) to other (t=1), with a linear interpolation for translation and scale and a slerp for rotation
+func (trs TRS) Lerp(other TRS, t float64) TRS {
+	return TRS{
+		Translation: trs.Translation.Mul(1 - t).Add(other.Translation.Mul(t)),
+		Rotation:    trs.Rotation.Slerp(other.Rotation, t),
+		Scale:       trs.Scale.Mul(1 - t).Add(other.Scale.Mul(t))}
+}
+
+// An instance of a Hittable object whose transform changes over time
+type AnimatedTransform struct {
+	object    Hittable // The original object is stored as-is, all the magic happens in the Hit() function
+	start     TRS
+	end       TRS
+	startTime float64
+	duration  float64
+	bbox      Aabb
+}
+
+// Animates the transform from start (time=0) to end (time=1)
+func NewAnimatedTransform(object Hittable, start, end TRS) AnimatedTransform {
+	return NewAnimatedTransformInTime(object, start, 0, end, 1)
+}
+
+// Animates the transform from start to end in the given scene times, it stays still before and after
+func NewAnimatedTransformInTime(object Hittable, start TRS, startTime float64, end TRS, endTime float64) AnimatedTransform {
+	at := AnimatedTransform{object: object, start: start, end: end, startTime: startTime, duration: endTime - startTime}
+
+	// The bounding box must cover the whole motion
+	at.bbox = motionBoundingBox(object.BoundingBox(), at.transformAt, startTime, endTime)
+
+	return at
+}
+
+func (at AnimatedTransform) trsAt(time float64) TRS {
+	if at.duration == 0 {
+		return at.start
+	}
+
+	return at.start.Lerp(at.end, animationFraction(time, at.startTime, at.duration))
+}
+
+func (at AnimatedTransform) transformAt(time float64) Mat4 {
+	return at.trsAt(time).Mat4()
+}
+
+func (at AnimatedTransform) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
+	trs := at.trsAt(ray.Time())
+	toObject, ok := trs.Inverse()
+
+	// The object is flattened to nothing at this time
+	if !ok {
+		return false
+	}
+
+	return hitTransformed(at.object, trs.Mat4(), toObject, ray, rayTmin, rayTmax, rec)
+}
+
+func (at AnimatedTransform) BoundingBox() Aabb {
+	return at.bbox
+}
+
+// An instance of a Hittable object whose transform follows keyframed tracks: translation, rotation as Euler angles
+// in degrees (applied around the X axis, then Y and then Z) and scale. Tracks that are nil leave the object unchanged.
+type KeyframedTransform struct {
+	object      Hittable // The original object is stored as-is, all the magic happens in the Hit() function
+	translation *Track
+	rotation    *Track
+	scale       *Track
+	bbox        Aabb
+}
+
+func NewKeyframedTransform(object Hittable, translation, rotation, scale *Track) KeyframedTransform {
+	kt := KeyframedTransform{object: object, translation: translation, rotation: rotation, scale: scale}
+
+	// The bounding box must cover the motion between the first and the last keyframe of all tracks
+	startTime, endTime := math.Inf(+1), math.Inf(-1)
+	for _, track := range []*Track{translation, rotation, scale} {
+		if track != nil {
+			first, last := track.TimeRange()
+			startTime, endTime = math.Min(startTime, first), math.Max(endTime, last)
+		}
+	}
+	if math.IsInf(startTime, +1) {
+		startTime, endTime = 0, 0
+	}
+
+	kt.bbox = motionBoundingBox(object.BoundingBox(), kt.transformAt, startTime, endTime)
+
+	return kt
+}
+
+func (kt KeyframedTransform) transformAt(time float64) Mat4 {
+	m, _, _ := kt.transformsAt(time)
+	return m
+}
+
+// Returns the transform at the given time and its inverse, or false if the transform is degenerate (a scale is 0)
+func (kt KeyframedTransform) transformsAt(time float64) (Mat4, Mat4, bool) {
+	m, inverse := IdentityMat4(), IdentityMat4()
+
+	if kt.scale != nil {
+		scale := kt.scale.At(time)
+		if scale.X == 0 || scale.Y == 0 || scale.Z == 0 {
+			return NewScaling(scale), Mat4{}, false
+		}
+		m, inverse = NewScaling(scale), NewScaling(NewVec3(1/scale.X, 1/scale.Y, 1/scale.Z))
+	}
+
+	// The inverse undoes the steps in the opposite order
+	if kt.rotation != nil {
+		angles := kt.rotation.At(time)
+		m = m.Then(NewRotationX(angles.X)).Then(NewRotationY(angles.Y)).Then(NewRotationZ(angles.Z))
+		inverse = NewRotationZ(-angles.Z).Then(NewRotationY(-angles.Y)).Then(NewRotationX(-angles.X)).Then(inverse)
+	}
+
+	if kt.translation != nil {
+		translation := kt.translation.At(time)
+		m = m.Then(NewTranslation(translation))
+		inverse = NewTranslation(translation.Negate()).Then(inverse)
+	}
+
+	return m, inverse, true
+}
+
+func (kt KeyframedTransform) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord) bool {
+	toWorld, toObject, ok := kt.transformsAt(ray.Time())
+
+	// The object is flattened to nothing at this time
+	if !ok {
+		return false
+	}
+
+	return hitTransformed(kt.object, toWorld, toObject, ray, rayTmin, rayTmax, rec)
+}
+
+func (kt KeyframedTransform) BoundingBox() Aabb {
+	return kt.bbox
+}
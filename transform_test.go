@@ -0,0 +1,189 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func nearVec3(a, b Vec3, tolerance float64) bool {
+	return a.Sub(b).Length() <= tolerance
+}
+
+func TestMat4Inverse(t *testing.T) {
+	m := NewShearX(0.3, -0.2).Then(NewScaling(NewVec3(2, 3, 0.5))).Then(NewRotation(NewVec3(1, 2, 3), 40)).Then(NewTranslation(NewVec3(5, -1, 2)))
+	inverse, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("%v is not invertible", m)
+	}
+
+	identity := m.Mul(inverse)
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(identity[i][j]-IdentityMat4()[i][j]) > 1e-12 {
+				t.Fatalf("m times its inverse is %v, not the identity", identity)
+			}
+		}
+	}
+}
+
+func TestSingularTransform(t *testing.T) {
+	flat := NewScaling(NewVec3(1, 0, 1))
+
+	if _, ok := flat.Inverse(); ok {
+		t.Errorf("a transform with a zero scale was inverted")
+	}
+
+	// The object is flattened to nothing
+	sphere := NewTransform(NewSphere(NewPoint3(0, 0, 0), 1, NewLambertianMaterial(Color{})), flat)
+
+	rec := HitRecord{}
+	if sphere.Hit(NewRay(NewPoint3(0, 0, -10), NewVec3(0, 0, 1), 0), 0.001, math.Inf(+1), &rec) {
+		t.Errorf("a sphere flattened by a zero scale is hit at %v", rec.P)
+	}
+}
+
+func TestShearX(t *testing.T) {
+	if p := NewShearX(0.5, 2).TransformPoint(NewPoint3(1, 2, 3)); !nearVec3(p, NewPoint3(8, 2, 3), 1e-12) {
+		t.Errorf("sheared point is %v, want {8 2 3}", p)
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	from, to := NewPoint3(1, 2, 3), NewPoint3(4, 6, 3)
+	m := NewLookAt(from, to, NewVec3(0, 1, 0))
+
+	if p := m.TransformPoint(NewPoint3(0, 0, 0)); !nearVec3(p, from, 1e-12) {
+		t.Errorf("the origin goes to %v, want %v", p, from)
+	}
+
+	if z := m.TransformVector(NewVec3(0, 0, 1)); !nearVec3(z, to.Sub(from).UnitVector(), 1e-12) {
+		t.Errorf("the Z axis goes to %v, want %v", z, to.Sub(from).UnitVector())
+	}
+
+	if y := m.TransformVector(NewVec3(0, 1, 0)); y.Dot(NewVec3(0, 1, 0)) <= 0 || math.Abs(y.Length()-1) > 1e-12 {
+		t.Errorf("the Y axis goes to %v, which doesn't point up", y)
+	}
+}
+
+func TestTransformHit(t *testing.T) {
+	// An ellipsoid with semi-axes 2, 1, 1 centered at 5, 0, 0
+	ellipsoid := NewTransform(NewSphere(NewPoint3(0, 0, 0), 1, NewLambertianMaterial(Color{})), NewScaling(NewVec3(2, 1, 1)).Then(NewTranslation(NewVec3(5, 0, 0))))
+
+	// The tangent is the direction of U on the sphere, stretched by the scaling
+	tests := []struct {
+		ray     Ray
+		p       Point3
+		normal  Vec3
+		tangent Vec3
+	}{
+		{NewRay(NewPoint3(0, 0, 0), NewVec3(1, 0, 0), 0), NewPoint3(3, 0, 0), NewVec3(-1, 0, 0), NewVec3(0, 0, 1)},
+		{NewRay(NewPoint3(5, 0, -10), NewVec3(0, 0, 2), 0), NewPoint3(5, 0, -1), NewVec3(0, 0, -1), NewVec3(-2, 0, 0)},
+		{NewRay(NewPoint3(6, 10, 0), NewVec3(0, -1, 0), 0), NewPoint3(6, math.Sqrt(0.75), 0), NewVec3(0.5, 2*math.Sqrt(0.75), 0).UnitVector(), NewVec3(0, 0, -0.5)},
+	}
+
+	for _, test := range tests {
+		rec := HitRecord{}
+
+		if !ellipsoid.Hit(test.ray, 0.001, math.Inf(+1), &rec) {
+			t.Errorf("ray %v misses the ellipsoid", test.ray)
+			continue
+		}
+
+		if !nearVec3(rec.P, test.p, 1e-9) || !nearVec3(rec.Normal, test.normal, 1e-9) {
+			t.Errorf("ray %v hits at %v with normal %v, want %v with normal %v", test.ray, rec.P, rec.Normal, test.p, test.normal)
+		}
+
+		if !nearVec3(rec.Tangent, test.tangent, 1e-9) {
+			t.Errorf("ray %v hits with tangent %v, want %v", test.ray, rec.Tangent, test.tangent)
+		}
+
+		if !nearVec3(test.ray.At(rec.T), rec.P, 1e-9) {
+			t.Errorf("ray %v: t %g is not the distance of the hit point along the ray", test.ray, rec.T)
+		}
+	}
+
+	if rec := (HitRecord{}); ellipsoid.Hit(NewRay(NewPoint3(0, 1.5, 0), NewVec3(1, 0, 0), 0), 0.001, math.Inf(+1), &rec) {
+		t.Errorf("a ray above the ellipsoid hits it at %v", rec.P)
+	}
+}
+
+func TestRotateYTangent(t *testing.T) {
+	// A square in the XY plane whose U goes along X, turned to face the X axis
+	quad := NewQuad(NewPoint3(-1, -1, 0), NewVec3(2, 0, 0), NewVec3(0, 2, 0), NewLambertianMaterial(Color{}))
+
+	rec := HitRecord{}
+	if !NewRotateY(quad, 90).Hit(NewRay(NewPoint3(10, 0, 0), NewVec3(-1, 0, 0), 0), 0.001, math.Inf(+1), &rec) {
+		t.Fatal("the ray misses the rotated square")
+	}
+
+	if !nearVec3(rec.Tangent, NewVec3(0, 0, -2), 1e-9) || math.Abs(rec.Tangent.Dot(rec.Normal)) > 1e-9 {
+		t.Errorf("the rotated square has tangent %v and normal %v, want tangent %v", rec.Tangent, rec.Normal, NewVec3(0, 0, -2))
+	}
+}
+
+func nearMat4(a, b Mat4, tolerance float64) bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(a[i][j]-b[i][j]) > tolerance {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func TestTRSFromMat4(t *testing.T) {
+	m := NewScaling(NewVec3(2, 3, 4)).Then(NewRotation(NewVec3(1, -2, 0.5), 70)).Then(NewTranslation(NewVec3(5, 6, 7)))
+	trs := NewTRSFromMat4(m)
+
+	if !nearMat4(trs.Mat4(), m, 1e-9) {
+		t.Errorf("the TRS gives back %v, want %v", trs.Mat4(), m)
+	}
+
+	inverse, ok := trs.Inverse()
+	if want, _ := m.Inverse(); !ok || !nearMat4(inverse, want, 1e-9) {
+		t.Errorf("the inverse of the TRS is %v, want %v", inverse, want)
+	}
+}
+
+func TestAnimatedTransformShrinksToNothing(t *testing.T) {
+	sphere := NewSphere(NewPoint3(0, 0, 0), 1, NewLambertianMaterial(Color{}))
+	shrinking := NewAnimatedTransform(sphere, NewTRS(NewVec3(0, 0, 0), IdentityQuaternion(), NewVec3(2, 2, 2)), NewTRS(NewVec3(0, 0, 0), IdentityQuaternion(), NewVec3(0, 0, 0)))
+
+	for _, test := range []struct{ time, radius float64 }{{0, 2}, {0.5, 1}, {1, 0}} {
+		rec := HitRecord{}
+		hit := shrinking.Hit(NewRay(NewPoint3(0, 0, -10), NewVec3(0, 0, 1), test.time), 0.001, math.Inf(+1), &rec)
+
+		if test.radius == 0 {
+			if hit {
+				t.Errorf("time %g: the sphere has no size but it's hit at %v", test.time, rec.P)
+			}
+		} else if !hit || math.Abs(rec.P.Z+test.radius) > 1e-9 {
+			t.Errorf("time %g: hit %t at %v, want a hit at z=%g", test.time, hit, rec.P, -test.radius)
+		}
+	}
+}
+
+func TestKeyframedTransformInverse(t *testing.T) {
+	sphere := NewSphere(NewPoint3(0, 0, 0), 1, NewLambertianMaterial(Color{}))
+	translation := NewTrack(InterpolationLinear).Add(0, NewVec3(0, 0, 0)).Add(1, NewVec3(4, 2, 0))
+	rotation := NewTrack(InterpolationLinear).Add(0, NewVec3(0, 0, 0)).Add(1, NewVec3(30, 60, 90))
+	scale := NewTrack(InterpolationLinear).Add(0, NewVec3(1, 2, 3)).Add(1, NewVec3(0, 0, 0))
+	kt := NewKeyframedTransform(sphere, translation, rotation, scale)
+
+	m, inverse, ok := kt.transformsAt(0.3)
+	if want, _ := m.Inverse(); !ok || !nearMat4(inverse, want, 1e-9) {
+		t.Errorf("the inverse at time 0.3 is %v, want %v", inverse, want)
+	}
+
+	if _, _, ok := kt.transformsAt(1); ok {
+		t.Error("the transform scaled to 0 is not degenerate")
+	}
+
+	rec := HitRecord{}
+	if kt.Hit(NewRay(NewPoint3(4, 2, -10), NewVec3(0, 0, 1), 1), 0.001, math.Inf(+1), &rec) {
+		t.Errorf("the sphere scaled to 0 is hit at %v", rec.P)
+	}
+}
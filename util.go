@@ -3,15 +3,28 @@ package main
 import (
 	"math"
 	"math/rand"
+	"time"
 )
 
+// All random numbers come from this generator, so that renders can be reproduced (and resumed) by seeding it
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
 func DegreesToRadians(degrees float64) float64 {
 	return degrees * math.Pi / 180
 }
 
+func RadiansToDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// Restarts the random number generator from the given seed
+func SeedRandom(seed int64) {
+	rng.Seed(seed)
+}
+
 // Returns a random number in the interval [0,1)
 func RandomDouble() float64 {
-	return rand.Float64()
+	return rng.Float64()
 }
 
 // Returns a random number in the interval [min, max)
@@ -19,11 +32,40 @@ func RandomDoubleInInterval(min, max float64) float64 {
 	return min + (max-min)*RandomDouble()
 }
 
+// Returns a random integer in the interval [0, n)
+func RandomInt(n int) int {
+	return rng.Intn(n)
+}
+
+// Returns how far an animation that starts at startTime and lasts duration has gone at the given time, from 0 to 1
+func animationFraction(time, startTime, duration float64) float64 {
+	return math.Max(0, math.Min(1, (time-startTime)/duration))
+}
+
 // Converts from linear to (approximately) gamma
 func LinearToGamma(linear float64) float64 {
 	return math.Sqrt(linear)
 }
 
+// Converts from (approximately) gamma to linear
+func GammaToLinear(gamma float64) float64 {
+	return gamma * gamma
+}
+
+// Converts a component of an sRGB color (e.g. a pixel of an image file), in [0,1], to linear with the exact sRGB curve
+func SRGBToLinear(srgb float64) float64 {
+	if srgb <= 0.04045 {
+		return srgb / 12.92
+	}
+
+	return math.Pow((srgb+0.055)/1.055, 2.4)
+}
+
+// Converts a linear color component to the standard RGB range, negative and NaN values are black
 func LinearToRGB(linear float64) int {
+	if !(linear > 0) {
+		return 0
+	}
+
 	return int(255.999 * LinearToGamma(math.Min(1, linear)))
 }
@@ -71,6 +71,7 @@ func (cm ConstantMedium) Hit(ray Ray, rayTmin, rayTmax float64, rec *HitRecord)
 	rec.Normal = NewVec3(1, 0, 0) // Arbitrary
 	rec.FrontFace = true          // Arbitrary
 	rec.Mat = cm.phaseFunction
+	rec.Tangent = Vec3{} // There's no surface
 
 	return true
 }